@@ -0,0 +1,114 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Attr is a bitmask of text attributes drawn with a Pixel
+type Attr uint16
+
+const (
+	AttrBold Attr = 1 << iota
+	AttrFaint
+	AttrItalic
+	// AttrUnderline draws a single underline
+	AttrUnderline
+	AttrDoubleUnderline
+	AttrCurlyUnderline
+	AttrDottedUnderline
+	AttrDashedUnderline
+	AttrBlink
+	AttrReverse
+	AttrStrikethrough
+)
+
+// AttrNone is the plain text style
+const AttrNone Attr = 0
+
+// attrNames lists the attributes in bit order for String
+var attrNames = []string{
+	"bold", "faint", "italic", "underline", "double-underline", "curly-underline",
+	"dotted-underline", "dashed-underline", "blink", "reverse", "strikethrough",
+}
+
+// String returns the attribute names joined with "|", or "none"
+func (a Attr) String() string {
+	if a == AttrNone {
+		return "none"
+	}
+	var names []string
+	for i, name := range attrNames {
+		if a&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// underlineStyle returns the SGR 4 sub-parameter of the underline drawn for a:
+// 0 for none, then single, double, curly, dotted and dashed. When several
+// styles are set the most specific one wins.
+func (a Attr) underlineStyle() int {
+	switch {
+	case a&AttrCurlyUnderline != 0:
+		return 3
+	case a&AttrDottedUnderline != 0:
+		return 4
+	case a&AttrDashedUnderline != 0:
+		return 5
+	case a&AttrDoubleUnderline != 0:
+		return 2
+	case a&AttrUnderline != 0:
+		return 1
+	}
+	return 0
+}
+
+// attrSGR returns the SGR parameters that change the attributes from to to
+func attrSGR(from, to Attr) []string {
+	var params []string
+
+	// Bold and faint share their reset (22)
+	intensity := AttrBold | AttrFaint
+	if from&intensity&^to != 0 {
+		params = append(params, "22")
+		from &^= intensity
+	}
+	if to&AttrBold != 0 && from&AttrBold == 0 {
+		params = append(params, "1")
+	}
+	if to&AttrFaint != 0 && from&AttrFaint == 0 {
+		params = append(params, "2")
+	}
+
+	params = toggleSGR(params, from, to, AttrItalic, "3", "23")
+
+	if style := to.underlineStyle(); style != from.underlineStyle() {
+		switch style {
+		case 0:
+			params = append(params, "24")
+		case 1:
+			params = append(params, "4")
+		default:
+			params = append(params, "4:"+strconv.Itoa(style))
+		}
+	}
+
+	params = toggleSGR(params, from, to, AttrBlink, "5", "25")
+	params = toggleSGR(params, from, to, AttrReverse, "7", "27")
+	params = toggleSGR(params, from, to, AttrStrikethrough, "9", "29")
+
+	return params
+}
+
+// toggleSGR appends on or off when attr differs between from and to
+func toggleSGR(params []string, from, to, attr Attr, on, off string) []string {
+	switch {
+	case to&attr != 0 && from&attr == 0:
+		return append(params, on)
+	case to&attr == 0 && from&attr != 0:
+		return append(params, off)
+	}
+	return params
+}
@@ -0,0 +1,188 @@
+package engine
+
+// brailleBase is the blank braille pattern; dots are added as bits
+const brailleBase = 0x2800
+
+// brailleDots maps a dot position within a cell, indexed as [y][x], to its
+// bit in the braille pattern
+var brailleDots = [4][2]uint8{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// BrailleCanvas is a monochrome-per-cell drawing surface with 2x4 dots per
+// terminal cell, drawn with the Unicode braille patterns (U+2800). It suits
+// line art and graphs. Each cell has a single color.
+type BrailleCanvas struct {
+	// Width and Height are the size in dots
+	Width  int
+	Height int
+	// Dots holds the dot pattern of each cell indexed as [row][col]
+	Dots [][]uint8
+	// Colors holds the color of each cell indexed as [row][col]
+	Colors [][]Color
+}
+
+// NewBrailleCanvas creates a braille canvas covering cols x rows terminal cells
+func NewBrailleCanvas(cols, rows int) *BrailleCanvas {
+	bc := &BrailleCanvas{
+		Width:  cols * 2,
+		Height: rows * 4,
+		Dots:   make([][]uint8, rows),
+		Colors: make([][]Color, rows),
+	}
+	for row := range rows {
+		bc.Dots[row] = make([]uint8, cols)
+		bc.Colors[row] = make([]Color, cols)
+	}
+	return bc
+}
+
+// Cells returns the size of the canvas in terminal cells
+func (bc *BrailleCanvas) Cells() (int, int) {
+	return (bc.Width + 1) / 2, (bc.Height + 3) / 4
+}
+
+// cell returns the cell and dot bit of the dot at x, y
+func (bc *BrailleCanvas) cell(x, y int) (col, row int, bit uint8, ok bool) {
+	if x < 0 || x >= bc.Width || y < 0 || y >= bc.Height {
+		return 0, 0, 0, false
+	}
+	return x / 2, y / 4, brailleDots[y%4][x%2], true
+}
+
+// Set turns on the dot at x, y, keeping the color of its cell
+func (bc *BrailleCanvas) Set(x, y int) {
+	if col, row, bit, ok := bc.cell(x, y); ok {
+		bc.Dots[row][col] |= bit
+	}
+}
+
+// SetColor turns on the dot at x, y and colors its cell
+func (bc *BrailleCanvas) SetColor(x, y int, c Color) {
+	if col, row, bit, ok := bc.cell(x, y); ok {
+		bc.Dots[row][col] |= bit
+		bc.Colors[row][col] = c
+	}
+}
+
+// Unset turns off the dot at x, y
+func (bc *BrailleCanvas) Unset(x, y int) {
+	if col, row, bit, ok := bc.cell(x, y); ok {
+		bc.Dots[row][col] &^= bit
+	}
+}
+
+// Toggle flips the dot at x, y
+func (bc *BrailleCanvas) Toggle(x, y int) {
+	if col, row, bit, ok := bc.cell(x, y); ok {
+		bc.Dots[row][col] ^= bit
+	}
+}
+
+// IsSet reports whether the dot at x, y is on
+func (bc *BrailleCanvas) IsSet(x, y int) bool {
+	col, row, bit, ok := bc.cell(x, y)
+	return ok && bc.Dots[row][col]&bit != 0
+}
+
+// SetCellColor colors the cell at col, row
+func (bc *BrailleCanvas) SetCellColor(col, row int, c Color) {
+	if row >= 0 && row < len(bc.Colors) && col >= 0 && col < len(bc.Colors[row]) {
+		bc.Colors[row][col] = c
+	}
+}
+
+// Clear turns off every dot and resets the cell colors
+func (bc *BrailleCanvas) Clear() {
+	for row := range bc.Dots {
+		clear(bc.Dots[row])
+		clear(bc.Colors[row])
+	}
+}
+
+// DrawLine turns on the dots of the line from x1, y1 to x2, y2, coloring the
+// cells it crosses
+func (bc *BrailleCanvas) DrawLine(x1, y1, x2, y2 int, c Color) {
+	plotLine(x1, y1, x2, y2, func(x, y int) {
+		bc.SetColor(x, y, c)
+	})
+}
+
+// DrawRect draws the outline of a w x h rectangle of dots
+func (bc *BrailleCanvas) DrawRect(x, y, w, h int, c Color) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	bc.DrawLine(x, y, x+w-1, y, c)
+	bc.DrawLine(x, y+h-1, x+w-1, y+h-1, c)
+	bc.DrawLine(x, y, x, y+h-1, c)
+	bc.DrawLine(x+w-1, y, x+w-1, y+h-1, c)
+}
+
+// FillRect turns on every dot of a w x h rectangle
+func (bc *BrailleCanvas) FillRect(x, y, w, h int, c Color) {
+	for i := max(y, 0); i < y+h && i < bc.Height; i++ {
+		for j := max(x, 0); j < x+w && j < bc.Width; j++ {
+			bc.SetColor(j, i, c)
+		}
+	}
+}
+
+// DrawCircle draws the outline of a circle of radius r around cx, cy using
+// the midpoint algorithm
+func (bc *BrailleCanvas) DrawCircle(cx, cy, r int, c Color) {
+	x, y := r, 0
+	d := 1 - r
+	for x >= y {
+		for _, p := range [8][2]int{
+			{x, y}, {y, x}, {-y, x}, {-x, y},
+			{-x, -y}, {-y, -x}, {y, -x}, {x, -y},
+		} {
+			bc.SetColor(cx+p[0], cy+p[1], c)
+		}
+		y++
+		if d < 0 {
+			d += 2*y + 1
+		} else {
+			x--
+			d += 2*(y-x) + 1
+		}
+	}
+}
+
+// DrawPolyline joins consecutive points with lines, e.g. to plot a graph.
+// points holds x, y pairs.
+func (bc *BrailleCanvas) DrawPolyline(points [][2]int, c Color) {
+	for i := 1; i < len(points); i++ {
+		bc.DrawLine(points[i-1][0], points[i-1][1], points[i][0], points[i][1], c)
+	}
+}
+
+// ToPixelBuffer renders the canvas into a new PixelBuffer of Cells() size
+func (bc *BrailleCanvas) ToPixelBuffer() *PixelBuffer {
+	cols, rows := bc.Cells()
+	pb := NewPixelBuffer(cols, rows)
+	bc.DrawTo(pb, 0, 0)
+	return pb
+}
+
+// DrawTo renders the canvas onto pb with its top left cell at col, row. Cells
+// without dots keep the pixel of pb; the others keep its background color.
+func (bc *BrailleCanvas) DrawTo(pb *PixelBuffer, col, row int) {
+	for cy, dots := range bc.Dots {
+		for cx, mask := range dots {
+			x, y := col+cx, row+cy
+			if mask == 0 || x < 0 || x >= pb.Width || y < 0 || y >= pb.Height {
+				continue
+			}
+			pb.SetPixel(x, y, Pixel{
+				Char: rune(brailleBase + int(mask)),
+				FG:   bc.Colors[cy][cx],
+				BG:   pb.Data[y][x].BG,
+			})
+		}
+	}
+}
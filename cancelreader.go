@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// errCanceled is returned by a cancelReader's Read once Cancel has been called
+var errCanceled = errors.New("read canceled")
+
+// cancelReader is an io.Reader whose blocking Read can be interrupted
+type cancelReader interface {
+	io.ReadCloser
+	// Cancel interrupts a pending Read and makes every later Read return
+	// errCanceled. It reports whether a blocked Read is guaranteed to return;
+	// the fallback reader can only notice the cancellation after the next read.
+	Cancel() bool
+}
+
+// fallbackCancelReader wraps readers that cannot be polled. A cancelled Read
+// still blocks until the underlying reader returns, then drops the data.
+type fallbackCancelReader struct {
+	r io.Reader
+
+	mtx      sync.Mutex
+	canceled bool
+}
+
+func newFallbackCancelReader(r io.Reader) *fallbackCancelReader {
+	return &fallbackCancelReader{r: r}
+}
+
+// Read reads from the wrapped reader unless the reader has been cancelled
+func (r *fallbackCancelReader) Read(p []byte) (int, error) {
+	if r.isCanceled() {
+		return 0, errCanceled
+	}
+
+	n, err := r.r.Read(p)
+
+	if r.isCanceled() {
+		return 0, errCanceled
+	}
+	return n, err
+}
+
+// Cancel marks the reader as cancelled; a blocked Read is not interrupted
+func (r *fallbackCancelReader) Cancel() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.canceled = true
+	return false
+}
+
+// Close does nothing, the wrapped reader is owned by the caller
+func (r *fallbackCancelReader) Close() error {
+	return nil
+}
+
+func (r *fallbackCancelReader) isCanceled() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.canceled
+}
@@ -0,0 +1,11 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package engine
+
+import "io"
+
+// newCancelReader returns a fallback reader on platforms without select(2) or
+// console input support
+func newCancelReader(r io.Reader) (cancelReader, error) {
+	return newFallbackCancelReader(r), nil
+}
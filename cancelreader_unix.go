@@ -0,0 +1,113 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package engine
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// selectCancelReader waits on the input file and a cancel pipe with select(2),
+// so Cancel interrupts a pending Read immediately
+type selectCancelReader struct {
+	file         *os.File
+	cancelSignal *os.File
+	cancelWriter *os.File
+
+	mtx      sync.Mutex
+	canceled bool
+	closed   bool
+}
+
+// newCancelReader returns a select based reader for files and a fallback
+// reader for everything else
+func newCancelReader(r io.Reader) (cancelReader, error) {
+	f, ok := r.(*os.File)
+	if !ok || f.Fd() >= unix.FD_SETSIZE {
+		return newFallbackCancelReader(r), nil
+	}
+
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	return &selectCancelReader{file: f, cancelSignal: pr, cancelWriter: pw}, nil
+}
+
+// Read blocks until the file is readable or the reader is cancelled
+func (r *selectCancelReader) Read(p []byte) (int, error) {
+	if r.isCanceled() {
+		return 0, errCanceled
+	}
+
+	if err := r.wait(); err != nil {
+		return 0, err
+	}
+
+	return r.file.Read(p)
+}
+
+// wait blocks in select(2) until the file or the cancel pipe is readable
+func (r *selectCancelReader) wait() error {
+	fd := int(r.file.Fd())
+	cancelFd := int(r.cancelSignal.Fd())
+
+	for {
+		var readSet unix.FdSet
+		readSet.Set(fd)
+		readSet.Set(cancelFd)
+
+		_, err := unix.Select(max(fd, cancelFd)+1, &readSet, nil, nil, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if readSet.IsSet(cancelFd) {
+			return errCanceled
+		}
+		if readSet.IsSet(fd) {
+			return nil
+		}
+	}
+}
+
+// Cancel wakes up a pending Read through the cancel pipe
+func (r *selectCancelReader) Cancel() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.closed {
+		return false
+	}
+
+	r.canceled = true
+	_, err := r.cancelWriter.Write([]byte{'c'})
+	return err == nil
+}
+
+// Close releases the cancel pipe; the input file is owned by the caller
+func (r *selectCancelReader) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	return errors.Join(r.cancelSignal.Close(), r.cancelWriter.Close())
+}
+
+func (r *selectCancelReader) isCanceled() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.canceled
+}
@@ -0,0 +1,175 @@
+//go:build windows
+
+package engine
+
+import (
+	"io"
+	"os"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	kernel32                          = windows.NewLazySystemDLL("kernel32.dll")
+	procGetNumberOfConsoleInputEvents = kernel32.NewProc("GetNumberOfConsoleInputEvents")
+	procPeekConsoleInputW             = kernel32.NewProc("PeekConsoleInputW")
+	procReadConsoleInputW             = kernel32.NewProc("ReadConsoleInputW")
+)
+
+// keyEvent is the EventType of a console INPUT_RECORD holding a key event
+const keyEvent = 0x0001
+
+// inputRecord is a console INPUT_RECORD with its union read as a KEY_EVENT_RECORD
+type inputRecord struct {
+	eventType uint16
+	_         uint16
+	keyDown   int32
+	repeat    uint16
+	keyCode   uint16
+	scanCode  uint16
+	char      uint16
+	ctrlState uint32
+}
+
+// consoleCancelReader waits on the console input handle and a cancel event,
+// so Cancel interrupts a pending Read immediately. The console handle is
+// signaled for any input event, including focus, mouse and key release events
+// that produce no bytes, so those are discarded before reading.
+type consoleCancelReader struct {
+	file        *os.File
+	cancelEvent windows.Handle
+
+	mtx      sync.Mutex
+	canceled bool
+	closed   bool
+}
+
+// newCancelReader returns a console reader for console input handles and a
+// fallback reader for everything else, such as pipes
+func newCancelReader(r io.Reader) (cancelReader, error) {
+	f, ok := r.(*os.File)
+	if !ok {
+		return newFallbackCancelReader(r), nil
+	}
+
+	var mode uint32
+	if err := windows.GetConsoleMode(windows.Handle(f.Fd()), &mode); err != nil {
+		return newFallbackCancelReader(r), nil
+	}
+
+	event, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &consoleCancelReader{file: f, cancelEvent: event}, nil
+}
+
+// Read blocks until a key press is available or the reader is cancelled
+func (r *consoleCancelReader) Read(p []byte) (int, error) {
+	if r.isCanceled() {
+		return 0, errCanceled
+	}
+
+	if err := r.wait(); err != nil {
+		return 0, err
+	}
+
+	return r.file.Read(p)
+}
+
+// wait blocks until the console holds a key press producing characters,
+// discarding the other input events, or until the cancel event is set
+func (r *consoleCancelReader) wait() error {
+	handle := windows.Handle(r.file.Fd())
+	handles := []windows.Handle{handle, r.cancelEvent}
+
+	for {
+		event, err := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+		if err != nil {
+			return err
+		}
+		if event == windows.WAIT_OBJECT_0+1 {
+			return errCanceled
+		}
+
+		records, err := peekConsoleInput(handle)
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			if rec.eventType == keyEvent && rec.keyDown != 0 && rec.char != 0 {
+				return nil
+			}
+		}
+		if err := discardConsoleInput(handle, len(records)); err != nil {
+			return err
+		}
+	}
+}
+
+// Cancel wakes up a pending Read through the cancel event
+func (r *consoleCancelReader) Cancel() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.closed {
+		return false
+	}
+
+	r.canceled = true
+	return windows.SetEvent(r.cancelEvent) == nil
+}
+
+// Close releases the cancel event; the input file is owned by the caller
+func (r *consoleCancelReader) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	return windows.CloseHandle(r.cancelEvent)
+}
+
+func (r *consoleCancelReader) isCanceled() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.canceled
+}
+
+// peekConsoleInput returns the pending console input events without removing them
+func peekConsoleInput(handle windows.Handle) ([]inputRecord, error) {
+	var n uint32
+	if r, _, err := procGetNumberOfConsoleInputEvents.Call(uintptr(handle), uintptr(unsafe.Pointer(&n))); r == 0 {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	records := make([]inputRecord, n)
+	var read uint32
+	if r, _, err := procPeekConsoleInputW.Call(uintptr(handle), uintptr(unsafe.Pointer(&records[0])), uintptr(n), uintptr(unsafe.Pointer(&read))); r == 0 {
+		return nil, err
+	}
+	return records[:read], nil
+}
+
+// discardConsoleInput removes the first n pending console input events
+func discardConsoleInput(handle windows.Handle, n int) error {
+	if n == 0 {
+		return nil
+	}
+
+	records := make([]inputRecord, n)
+	var read uint32
+	if r, _, err := procReadConsoleInputW.Call(uintptr(handle), uintptr(unsafe.Pointer(&records[0])), uintptr(n), uintptr(unsafe.Pointer(&read))); r == 0 {
+		return err
+	}
+	return nil
+}
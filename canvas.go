@@ -0,0 +1,215 @@
+package engine
+
+// CanvasMode selects how many canvas pixels a terminal cell shows
+type CanvasMode int
+
+const (
+	// HalfBlocks maps 1x2 pixels onto each cell with ▀ and ▄, which gives
+	// square pixels on most terminal fonts
+	HalfBlocks CanvasMode = iota
+	// QuadrantBlocks maps 2x2 pixels onto each cell with the quadrant block
+	// characters. A cell shows at most two colors, so cells with more are
+	// approximated.
+	QuadrantBlocks
+)
+
+// PixelsPerCell returns how many canvas pixels make up one cell horizontally
+// and vertically
+func (m CanvasMode) PixelsPerCell() (int, int) {
+	if m == QuadrantBlocks {
+		return 2, 2
+	}
+	return 1, 2
+}
+
+// quadrantRunes holds the block drawing the set quadrants of a mask where
+// bit 0 is top left, 1 top right, 2 bottom left and 3 bottom right
+var quadrantRunes = [16]rune{
+	' ', '▘', '▝', '▀', '▖', '▌', '▞', '▛', '▗', '▚', '▐', '▜', '▄', '▙', '▟', '█',
+}
+
+// Canvas is a high-resolution drawing surface of colored pixels smaller than a
+// terminal cell. Pixels left at ColorDefault are transparent.
+type Canvas struct {
+	// Width and Height are the size in pixels
+	Width  int
+	Height int
+	Mode   CanvasMode
+	// Data holds the pixels indexed as [y][x]
+	Data [][]Color
+}
+
+// NewCanvas creates a canvas covering cols x rows terminal cells
+func NewCanvas(cols, rows int, mode CanvasMode) *Canvas {
+	pw, ph := mode.PixelsPerCell()
+	c := &Canvas{
+		Width:  cols * pw,
+		Height: rows * ph,
+		Mode:   mode,
+	}
+	c.Data = make([][]Color, c.Height)
+	for y := range c.Data {
+		c.Data[y] = make([]Color, c.Width)
+	}
+	return c
+}
+
+// Cells returns the size of the canvas in terminal cells
+func (c *Canvas) Cells() (int, int) {
+	pw, ph := c.Mode.PixelsPerCell()
+	return (c.Width + pw - 1) / pw, (c.Height + ph - 1) / ph
+}
+
+// Set colors the pixel at x, y
+func (c *Canvas) Set(x, y int, color Color) {
+	if x >= 0 && x < c.Width && y >= 0 && y < c.Height {
+		c.Data[y][x] = color
+	}
+}
+
+// At returns the color of the pixel at x, y, or ColorDefault out of bounds
+func (c *Canvas) At(x, y int) Color {
+	if x >= 0 && x < c.Width && y >= 0 && y < c.Height {
+		return c.Data[y][x]
+	}
+	return ColorDefault
+}
+
+// Clear makes every pixel transparent
+func (c *Canvas) Clear() {
+	for y := range c.Data {
+		clear(c.Data[y])
+	}
+}
+
+// FillRect colors a w x h rectangle of pixels
+func (c *Canvas) FillRect(x, y, w, h int, color Color) {
+	for i := max(y, 0); i < y+h && i < c.Height; i++ {
+		for j := max(x, 0); j < x+w && j < c.Width; j++ {
+			c.Data[i][j] = color
+		}
+	}
+}
+
+// DrawLine colors the pixels of the line from x1, y1 to x2, y2
+func (c *Canvas) DrawLine(x1, y1, x2, y2 int, color Color) {
+	plotLine(x1, y1, x2, y2, func(x, y int) {
+		c.Set(x, y, color)
+	})
+}
+
+// ToPixelBuffer renders the canvas into a new PixelBuffer of Cells() size
+func (c *Canvas) ToPixelBuffer() *PixelBuffer {
+	cols, rows := c.Cells()
+	pb := NewPixelBuffer(cols, rows)
+	c.DrawTo(pb, 0, 0)
+	return pb
+}
+
+// DrawTo renders the canvas onto pb with its top left cell at col, row. Cells
+// whose pixels are all transparent keep the pixel of pb, and transparent
+// pixels next to colored ones show the background color of pb.
+func (c *Canvas) DrawTo(pb *PixelBuffer, col, row int) {
+	cols, rows := c.Cells()
+	pw, ph := c.Mode.PixelsPerCell()
+
+	for cy := 0; cy < rows; cy++ {
+		for cx := 0; cx < cols; cx++ {
+			x, y := col+cx, row+cy
+			if x < 0 || x >= pb.Width || y < 0 || y >= pb.Height {
+				continue
+			}
+
+			var pixels [4]Color
+			transparent := true
+			for i := range pw * ph {
+				pixels[i] = c.At(cx*pw+i%pw, cy*ph+i/pw)
+				if !pixels[i].IsDefault() {
+					transparent = false
+				}
+			}
+			if transparent {
+				continue
+			}
+
+			bg := pb.Data[y][x].BG
+			for i := range pw * ph {
+				if pixels[i].IsDefault() {
+					pixels[i] = bg
+				}
+			}
+
+			if c.Mode == QuadrantBlocks {
+				pb.SetPixel(x, y, quadrantCell(pixels))
+			} else {
+				pb.SetPixel(x, y, halfBlockCell(pixels[0], pixels[1]))
+			}
+		}
+	}
+}
+
+// halfBlockCell draws a top and a bottom pixel. The foreground is never the
+// default color, which would show the terminal's text color.
+func halfBlockCell(top, bottom Color) Pixel {
+	switch {
+	case top == bottom:
+		return Pixel{Char: ' ', BG: top}
+	case top.IsDefault():
+		return Pixel{Char: '▄', FG: bottom, BG: top}
+	}
+	return Pixel{Char: '▀', FG: top, BG: bottom}
+}
+
+// quadrantCell draws 2x2 pixels ordered top left, top right, bottom left,
+// bottom right. With more than two colors the two most frequent are kept and
+// the other pixels take the closer of them.
+func quadrantCell(pixels [4]Color) Pixel {
+	var colors [4]Color
+	var counts [4]int
+	n := 0
+	for _, p := range pixels {
+		i := 0
+		for i < n && colors[i] != p {
+			i++
+		}
+		if i == n {
+			colors[n] = p
+			n++
+		}
+		counts[i]++
+	}
+	if n == 1 {
+		return Pixel{Char: ' ', BG: colors[0]}
+	}
+
+	// Keep the two most frequent colors, earlier ones first on ties
+	first, second := 0, -1
+	for i := 1; i < n; i++ {
+		switch {
+		case counts[i] > counts[first]:
+			first, second = i, first
+		case second < 0 || counts[i] > counts[second]:
+			second = i
+		}
+	}
+	fg, bg := colors[first], colors[second]
+	if fg.IsDefault() {
+		fg, bg = bg, fg
+	}
+
+	mask := 0
+	for i, p := range pixels {
+		if p == fg || (p != bg && closerTo(p, fg, bg)) {
+			mask |= 1 << i
+		}
+	}
+	return Pixel{Char: quadrantRunes[mask], FG: fg, BG: bg}
+}
+
+// closerTo reports whether c is closer to a than to b
+func closerTo(c, a, b Color) bool {
+	r, g, bl := colorRGB(c)
+	ar, ag, ab := colorRGB(a)
+	br, bg, bb := colorRGB(b)
+	return colorDistance(r, g, bl, ar, ag, ab) < colorDistance(r, g, bl, br, bg, bb)
+}
@@ -0,0 +1,64 @@
+package engine_test
+
+import (
+	"testing"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// quadrants draws one quadrant pattern per cell of a quadrant canvas
+type quadrants struct{}
+
+func (m quadrants) Init() engine.Msg { return nil }
+
+func (m quadrants) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	if _, ok := msg.(engine.KeyMsg); ok {
+		return m, engine.Quit
+	}
+	return m, nil
+}
+
+func (m quadrants) View() string { return "" }
+
+func (m quadrants) CanvasView() *engine.Canvas {
+	red, blue, green := engine.ColorRed, engine.ColorBlue, engine.ColorGreen
+	patterns := [][4]engine.Color{
+		{red, engine.ColorDefault, engine.ColorDefault, engine.ColorDefault},
+		{red, red, engine.ColorDefault, engine.ColorDefault},
+		{red, blue, blue, red},
+		{green, green, green, green},
+		// Dark red is closer to red than to blue, so it is drawn red
+		{red, red, blue, engine.RGB(200, 0, 0)},
+	}
+
+	c := engine.NewCanvas(len(patterns), 1, engine.QuadrantBlocks)
+	for cell, pixels := range patterns {
+		for i, color := range pixels {
+			c.Set(cell*2+i%2, i/2, color)
+		}
+	}
+	return c
+}
+
+func TestQuadrantCanvas(t *testing.T) {
+	tm := enginetest.NewTestModel(t, quadrants{}, enginetest.WithInitialSize(10, 2),
+		enginetest.WithColorProfile(engine.ANSI))
+	tm.WaitFor(func(s *enginetest.Screen) bool { return s.Cell(0, 0).Content == "▘" })
+
+	want := []enginetest.Cell{
+		{Content: "▘", Style: "31"},
+		{Content: "▀", Style: "31"},
+		{Content: "▚", Style: "31;44"},
+		{Content: " ", Style: "42"},
+		{Content: "▜", Style: "31;44"},
+	}
+	for x, cell := range want {
+		if got := tm.Screen().Cell(x, 0); got != cell {
+			t.Errorf("cell %d = %+v, want %+v", x, got, cell)
+		}
+	}
+
+	tm.Type("q")
+	tm.WaitFinished()
+}
@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// cellPen is the SGR state of the terminal while a cell frame is written
+type cellPen struct {
+	fg    Color
+	bg    Color
+	ul    Color
+	attrs Attr
+	valid bool
+}
+
+// writeCells stores buffer as the next frame. In the alternate screen the frame
+// is diffed against the previous one cell by cell and only changed cells are
+// written; in inline mode it falls back to the line based string renderer.
+func (r *StandardRenderer) writeCells(buffer *PixelBuffer) {
+	r.mtx.Lock()
+	altScreen := r.altScreenActive
+	if altScreen {
+		if r.pending {
+			// The previous frame was never flushed
+			r.stats.Skipped++
+		}
+		r.nextCells = buffer.Clone()
+		r.pending = true
+	}
+	flush := r.onDemand && r.running
+	r.mtx.Unlock()
+
+	if !altScreen {
+		// Drop the newline after the last row, which would add a blank line
+		r.Write(strings.TrimSuffix(buffer.RenderToTerminal(), "\n"))
+		return
+	}
+	if flush {
+		r.flush()
+	}
+}
+
+// flushCells writes the difference between the front buffer (what the terminal
+// shows) and the pending back buffer. The caller holds r.mtx.
+func (r *StandardRenderer) flushCells() {
+	back := r.nextCells
+	r.nextCells = nil
+	r.pending = false
+
+	front := r.lastCells
+	buf := &bytes.Buffer{}
+
+	if front == nil || front.Width != back.Width || front.Height != back.Height {
+		buf.WriteString(ansi.ResetStyle)
+		if len(r.ignoreLines) == 0 {
+			buf.WriteString(ansi.EraseEntireScreen)
+		} else {
+			// Keep the lines painted by another writer
+			for y := 0; y < max(r.height, back.Height); y++ {
+				if _, ignore := r.ignoreLines[y]; !ignore {
+					buf.WriteString(ansi.CursorPosition(1, y+1))
+					buf.WriteString(ansi.EraseEntireLine)
+				}
+			}
+		}
+		front = nil
+	}
+
+	width, height := back.Width, back.Height
+	if r.width > 0 {
+		width = min(width, r.width)
+	}
+	if r.height > 0 {
+		height = min(height, r.height)
+	}
+
+	var pen cellPen
+	cursorX, cursorY := -1, -1
+
+	for y := 0; y < height; y++ {
+		if _, ignore := r.ignoreLines[y]; ignore {
+			continue
+		}
+		for x := 0; x < width; x++ {
+			cell := back.Data[y][x]
+			if cell.IsContinuation() {
+				// Drawn together with the wide glyph to the left
+				continue
+			}
+			if front != nil && front.Data[y][x] == cell {
+				continue
+			}
+
+			content, cellWidth := cell.Content(), cell.Width()
+			if x+cellWidth > width {
+				// The terminal is narrower than the frame
+				content, cellWidth = " ", 1
+			}
+
+			moveCursor(buf, cursorX, cursorY, x, y)
+			pen = writePen(buf, pen, cell, r.colorProfile)
+			buf.WriteString(content)
+
+			cursorX, cursorY = x+cellWidth, y
+			if cursorX >= width {
+				// Avoid relying on the terminal's pending-wrap behaviour
+				cursorX, cursorY = -1, -1
+			}
+		}
+	}
+
+	if pen.valid {
+		buf.WriteString(ansi.ResetStyle)
+	}
+
+	r.lastCells = back
+	r.altLinesRendered = height
+
+	if buf.Len() == 0 {
+		r.stats.Skipped++
+		return
+	}
+	r.writeFrame(buf)
+	r.stats.Rendered++
+}
+
+// moveCursor emits the shortest supported sequence moving the cursor from
+// (fromX, fromY) to (x, y). A negative fromX means the position is unknown.
+func moveCursor(buf *bytes.Buffer, fromX, fromY, x, y int) {
+	switch {
+	case fromX == x && fromY == y:
+	case fromY == y && fromX >= 0 && x > fromX:
+		buf.WriteString(ansi.CursorForward(x - fromX))
+	case fromY == y && fromX >= 0:
+		buf.WriteString(ansi.CursorHorizontalAbsolute(x + 1))
+	default:
+		buf.WriteString(ansi.CursorPosition(x+1, y+1))
+	}
+}
+
+// writePen emits a single SGR sequence containing only the attributes of cell
+// that differ from the current pen and returns the updated pen. Colors are
+// converted for profile first.
+func writePen(buf *bytes.Buffer, pen cellPen, cell Pixel, profile ColorProfile) cellPen {
+	fg, bg, ul := profile.Convert(cell.FG), profile.Convert(cell.BG), profile.Convert(cell.UnderlineColor)
+
+	var params []string
+	if !pen.valid || pen.fg != fg {
+		params = append(params, fg.sgr(false))
+	}
+	if !pen.valid || pen.bg != bg {
+		params = append(params, bg.sgr(true))
+	}
+	if !pen.valid {
+		// The pen starts from the reset state written before the frame
+		pen.attrs, pen.ul = AttrNone, ColorDefault
+	}
+	params = append(params, attrSGR(pen.attrs, cell.Attrs)...)
+	if pen.ul != ul {
+		params = append(params, ul.underlineSGR())
+	}
+
+	if len(params) > 0 {
+		buf.WriteString("\x1b[")
+		for i, p := range params {
+			if i > 0 {
+				buf.WriteByte(';')
+			}
+			buf.WriteString(p)
+		}
+		buf.WriteByte('m')
+	}
+
+	return cellPen{fg: fg, bg: bg, ul: ul, attrs: cell.Attrs, valid: true}
+}
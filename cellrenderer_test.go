@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// animatedScene draws frame of a 200x60 scene: a gradient background with
+// five characters moving across it
+func animatedScene(frame int) *PixelBuffer {
+	const width, height = 200, 60
+
+	pb := NewPixelBuffer(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pb.Data[y][x] = Pixel{
+				Char: '.',
+				FG:   RGB(uint8(x), uint8(y*4), 128),
+				BG:   RGB(0, 0, uint8(y*2)),
+			}
+		}
+	}
+	for i := 0; i < 5; i++ {
+		x := (frame + i*40) % width
+		y := (i*12 + frame/3) % height
+		pb.SetPixel(x, y, Pixel{Char: '@', FG: ColorRed, Attrs: AttrBold})
+	}
+	return pb
+}
+
+// newBenchRenderer returns an on-demand pixel renderer drawing a 200x60
+// alternate screen into out. Buffers are not terminals, so the true color
+// profile is set explicitly rather than detected.
+func newBenchRenderer(out io.Writer) *PixelRenderer {
+	r := NewPixelRenderer(out).(*PixelRenderer)
+	r.SetColorProfile(TrueColor)
+	r.SetFrameRate(0)
+	r.Start()
+	r.EnterAltScreen()
+	r.Resize(200, 60)
+	return r
+}
+
+// BenchmarkCellDiff compares the bytes written per frame of the animated scene
+// by the cell diff (RenderPixels in the alternate screen) with the line based
+// renderer used for string views and inline pixel frames
+func BenchmarkCellDiff(b *testing.B) {
+	b.Run("cells", func(b *testing.B) {
+		r := newBenchRenderer(io.Discard)
+		defer r.Stop()
+
+		r.RenderPixels(animatedScene(0))
+		before := r.Stats()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			r.RenderPixels(animatedScene(i + 1))
+		}
+
+		b.StopTimer()
+		b.ReportMetric(float64(r.Stats().Bytes-before.Bytes)/float64(b.N), "bytes/frame")
+	})
+
+	b.Run("lines", func(b *testing.B) {
+		r := newBenchRenderer(io.Discard)
+		defer r.Stop()
+
+		r.Write(animatedScene(0).RenderToTerminal())
+		before := r.Stats()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			r.Write(animatedScene(i + 1).RenderToTerminal())
+		}
+
+		b.StopTimer()
+		b.ReportMetric(float64(r.Stats().Bytes-before.Bytes)/float64(b.N), "bytes/frame")
+	})
+}
+
+func TestCellDiffWritesChangedCells(t *testing.T) {
+	var out bytes.Buffer
+	r := newBenchRenderer(&out)
+	defer r.Stop()
+
+	r.RenderPixels(animatedScene(0))
+	full := out.Len()
+	out.Reset()
+
+	r.RenderPixels(animatedScene(1))
+	if out.Len() == 0 || out.Len() > full/100 {
+		t.Errorf("diff frame is %d bytes, full frame %d", out.Len(), full)
+	}
+	if n := strings.Count(out.String(), "@"); n != 5 {
+		t.Errorf("diff frame draws %d characters, want 5", n)
+	}
+
+	out.Reset()
+	r.RenderPixels(animatedScene(1))
+	if out.Len() != 0 {
+		t.Errorf("unchanged frame wrote %q", out.String())
+	}
+}
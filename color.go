@@ -0,0 +1,160 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Color is a terminal color. The zero value is the terminal's default color;
+// other values are one of the 16 ANSI colors, an entry of the 256-color
+// palette or a 24-bit RGB color. Colors are comparable with ==.
+type Color uint32
+
+// The top byte of a Color holds its kind, the low 24 bits its value
+const (
+	colorKindMask Color = 0xff << 24
+	colorKindANSI Color = 1 << 24
+	colorKind256  Color = 2 << 24
+	colorKindRGB  Color = 3 << 24
+)
+
+// ColorDefault is the terminal's default foreground or background color
+const ColorDefault Color = 0
+
+// The 16 ANSI colors. Their actual appearance depends on the terminal theme.
+const (
+	ColorBlack Color = colorKindANSI | iota
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+	ColorBrightBlack
+	ColorBrightRed
+	ColorBrightGreen
+	ColorBrightYellow
+	ColorBrightBlue
+	ColorBrightMagenta
+	ColorBrightCyan
+	ColorBrightWhite
+)
+
+// ANSIColor returns one of the 16 ANSI colors; n is taken modulo 16
+func ANSIColor(n uint8) Color {
+	return colorKindANSI | Color(n%16)
+}
+
+// ANSI256Color returns entry n of the 256-color palette
+func ANSI256Color(n uint8) Color {
+	return colorKind256 | Color(n)
+}
+
+// RGB returns a 24-bit color
+func RGB(r, g, b uint8) Color {
+	return colorKindRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+// Hex parses a "#rrggbb" or "#rgb" color
+func Hex(s string) (Color, error) {
+	c, err := colorful.Hex(s)
+	if err != nil {
+		return ColorDefault, err
+	}
+	return RGB(c.RGB255()), nil
+}
+
+// HSL returns the 24-bit color for hue h in [0, 360), saturation s and
+// lightness l in [0, 1]
+func HSL(h, s, l float64) Color {
+	return RGB(colorful.Hsl(h, s, l).Clamped().RGB255())
+}
+
+// IsDefault reports whether c is the terminal's default color
+func (c Color) IsDefault() bool {
+	return c&colorKindMask == 0
+}
+
+// IsANSI reports whether c is one of the 16 ANSI colors
+func (c Color) IsANSI() bool {
+	return c&colorKindMask == colorKindANSI
+}
+
+// Is256 reports whether c is an entry of the 256-color palette
+func (c Color) Is256() bool {
+	return c&colorKindMask == colorKind256
+}
+
+// IsRGB reports whether c is a 24-bit color
+func (c Color) IsRGB() bool {
+	return c&colorKindMask == colorKindRGB
+}
+
+// Index returns the palette index of an ANSI or 256-color value
+func (c Color) Index() uint8 {
+	return uint8(c)
+}
+
+// RGB returns the components of a 24-bit color
+func (c Color) RGB() (r, g, b uint8) {
+	return uint8(c >> 16), uint8(c >> 8), uint8(c)
+}
+
+// String returns a readable form of the color, e.g. "default", "ansi(1)",
+// "256(208)" or "#ff8800"
+func (c Color) String() string {
+	switch c & colorKindMask {
+	case colorKindANSI:
+		return "ansi(" + strconv.Itoa(int(c.Index())) + ")"
+	case colorKind256:
+		return "256(" + strconv.Itoa(int(c.Index())) + ")"
+	case colorKindRGB:
+		r, g, b := c.RGB()
+		return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	default:
+		return "default"
+	}
+}
+
+// sgr returns the SGR parameters selecting c as the foreground color, or as
+// the background color when bg is set
+func (c Color) sgr(bg bool) string {
+	base := 30
+	if bg {
+		base = 40
+	}
+
+	switch c & colorKindMask {
+	case colorKindANSI:
+		n := int(c.Index())
+		if n >= 8 {
+			// Bright colors use 90-97 and 100-107
+			return strconv.Itoa(base + 60 + n - 8)
+		}
+		return strconv.Itoa(base + n)
+	case colorKind256:
+		return strconv.Itoa(base+8) + ";5;" + strconv.Itoa(int(c.Index()))
+	case colorKindRGB:
+		r, g, b := c.RGB()
+		return strconv.Itoa(base+8) + ";2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+	default:
+		return strconv.Itoa(base + 9)
+	}
+}
+
+// underlineSGR returns the SGR parameters selecting c as the underline color.
+// Underline colors have no short form, so ANSI colors use the palette form.
+func (c Color) underlineSGR() string {
+	switch c & colorKindMask {
+	case colorKindANSI, colorKind256:
+		return "58;5;" + strconv.Itoa(int(c.Index()))
+	case colorKindRGB:
+		r, g, b := c.RGB()
+		return "58;2;" + strconv.Itoa(int(r)) + ";" + strconv.Itoa(int(g)) + ";" + strconv.Itoa(int(b))
+	default:
+		return "59"
+	}
+}
@@ -0,0 +1,66 @@
+package engine
+
+import "testing"
+
+func TestColorConstructors(t *testing.T) {
+	tests := []struct {
+		c      Color
+		want   string
+		fg, bg string
+		ul     string
+	}{
+		{ColorDefault, "default", "39", "49", "59"},
+		{ColorRed, "ansi(1)", "31", "41", "58;5;1"},
+		{ColorBrightRed, "ansi(9)", "91", "101", "58;5;9"},
+		{ANSIColor(17), "ansi(1)", "31", "41", "58;5;1"},
+		{ANSI256Color(208), "256(208)", "38;5;208", "48;5;208", "58;5;208"},
+		{RGB(255, 136, 0), "#ff8800", "38;2;255;136;0", "48;2;255;136;0", "58;2;255;136;0"},
+		{HSL(0, 1, 0.5), "#ff0000", "38;2;255;0;0", "48;2;255;0;0", "58;2;255;0;0"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+		if got := tt.c.sgr(false); got != tt.fg {
+			t.Errorf("%v: foreground SGR = %q, want %q", tt.c, got, tt.fg)
+		}
+		if got := tt.c.sgr(true); got != tt.bg {
+			t.Errorf("%v: background SGR = %q, want %q", tt.c, got, tt.bg)
+		}
+		if got := tt.c.underlineSGR(); got != tt.ul {
+			t.Errorf("%v: underline SGR = %q, want %q", tt.c, got, tt.ul)
+		}
+	}
+}
+
+func TestColorKinds(t *testing.T) {
+	tests := []struct {
+		c                    Color
+		def, ansi, c256, rgb bool
+	}{
+		{ColorDefault, true, false, false, false},
+		{ColorBlack, false, true, false, false},
+		{ANSI256Color(0), false, false, true, false},
+		{RGB(0, 0, 0), false, false, false, true},
+	}
+
+	for _, tt := range tests {
+		if tt.c.IsDefault() != tt.def || tt.c.IsANSI() != tt.ansi || tt.c.Is256() != tt.c256 || tt.c.IsRGB() != tt.rgb {
+			t.Errorf("%v: IsDefault=%v IsANSI=%v Is256=%v IsRGB=%v", tt.c,
+				tt.c.IsDefault(), tt.c.IsANSI(), tt.c.Is256(), tt.c.IsRGB())
+		}
+	}
+}
+
+func TestHex(t *testing.T) {
+	for _, s := range []string{"#ff8800", "#f80"} {
+		c, err := Hex(s)
+		if err != nil || c != RGB(255, 136, 0) {
+			t.Errorf("Hex(%q) = %v, %v", s, c, err)
+		}
+	}
+	if _, err := Hex("orange"); err == nil {
+		t.Error("Hex accepted an invalid color")
+	}
+}
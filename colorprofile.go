@@ -0,0 +1,345 @@
+package engine
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ColorProfile is the set of colors a terminal can display. Profiles are
+// ordered from most to fewest colors.
+type ColorProfile int
+
+const (
+	// TrueColor terminals display 24-bit RGB colors
+	TrueColor ColorProfile = iota
+	// ANSI256 terminals display the 256-color palette
+	ANSI256
+	// ANSI terminals display the 16 ANSI colors
+	ANSI
+	// NoColor output carries no colors at all
+	NoColor
+)
+
+// String returns the name of the profile
+func (p ColorProfile) String() string {
+	switch p {
+	case TrueColor:
+		return "TrueColor"
+	case ANSI256:
+		return "ANSI256"
+	case ANSI:
+		return "ANSI"
+	case NoColor:
+		return "NoColor"
+	}
+	return "ColorProfile(" + strconv.Itoa(int(p)) + ")"
+}
+
+// DetectColorProfile returns the color profile of the terminal behind w.
+// NO_COLOR disables colors, as does output that is not a terminal unless
+// CLICOLOR_FORCE is set. Otherwise COLORTERM, TERM and TERM_PROGRAM decide.
+func DetectColorProfile(w io.Writer) ColorProfile {
+	_, isTTY := terminalFd(w)
+	return detectColorProfile(isTTY, os.Getenv)
+}
+
+func detectColorProfile(isTTY bool, getenv func(string) string) ColorProfile {
+	if getenv("NO_COLOR") != "" {
+		return NoColor
+	}
+	if !isTTY {
+		if force := getenv("CLICOLOR_FORCE"); force == "" || force == "0" {
+			return NoColor
+		}
+	}
+
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+
+	termName := strings.ToLower(getenv("TERM"))
+	switch {
+	case termName == "dumb":
+		return NoColor
+	case termName == "linux", strings.HasPrefix(termName, "vt"):
+		// The Linux console and VT emulations only know the 16 ANSI colors
+		return ANSI
+	case strings.Contains(termName, "truecolor"), strings.Contains(termName, "24bit"),
+		strings.Contains(termName, "direct"), strings.Contains(termName, "kitty"),
+		strings.Contains(termName, "ghostty"), strings.Contains(termName, "alacritty"),
+		strings.Contains(termName, "wezterm"), strings.Contains(termName, "foot"):
+		return TrueColor
+	}
+
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return TrueColor
+	case "Apple_Terminal":
+		return ANSI256
+	}
+
+	if strings.Contains(termName, "256color") {
+		return ANSI256
+	}
+
+	if termName == "" && runtime.GOOS == "windows" {
+		// Windows Terminal and recent conhost support 24-bit colors
+		if getenv("WT_SESSION") != "" {
+			return TrueColor
+		}
+		return ANSI256
+	}
+
+	return ANSI
+}
+
+// Convert returns the closest color to c that the profile can display
+func (p ColorProfile) Convert(c Color) Color {
+	switch p {
+	case NoColor:
+		return ColorDefault
+	case ANSI:
+		switch {
+		case c.Is256():
+			return ANSIColor(ansi256To16[c.Index()])
+		case c.IsRGB():
+			return ANSIColor(nearestANSI(c.RGB()))
+		}
+	case ANSI256:
+		if c.IsRGB() {
+			return ANSI256Color(rgbTo256(c.RGB()))
+		}
+	}
+	return c
+}
+
+// ConvertString rewrites the colors of the SGR sequences in s for the
+// profile. Other attributes and escape sequences are kept as they are.
+func (p ColorProfile) ConvertString(s string) string {
+	if p == TrueColor || !strings.Contains(s, "\x1b[") {
+		return s
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+
+	parser := ansi.NewParser()
+	var state byte
+	data := s
+	for len(data) > 0 {
+		seq, _, n, newState := ansi.DecodeSequence(data, state, parser)
+		state = newState
+		data = data[n:]
+
+		cmd := ansi.Cmd(parser.Command())
+		if !ansi.HasCsiPrefix(seq) || cmd.Final() != 'm' || cmd.Prefix() != 0 || cmd.Intermediate() != 0 {
+			sb.WriteString(seq)
+			continue
+		}
+
+		params := parser.Params()
+		if len(params) == 0 {
+			sb.WriteString(seq)
+			continue
+		}
+		if converted := p.convertSGR(params); converted != "" {
+			sb.WriteString("\x1b[" + converted + "m")
+		}
+	}
+
+	return sb.String()
+}
+
+// convertSGR re-encodes SGR parameters with their colors converted for the
+// profile. It returns "" when nothing is left.
+func (p ColorProfile) convertSGR(params ansi.Params) string {
+	var out []string
+	for i := 0; i < len(params); i++ {
+		v := params[i].Param(0)
+
+		// Colon separated sub-parameters belong to the same attribute
+		group := []int{v}
+		for params[i].HasMore() && i+1 < len(params) {
+			i++
+			group = append(group, params[i].Param(-1))
+		}
+
+		if len(group) == 1 && (v == 38 || v == 48 || v == 58) && i+1 < len(params) {
+			// Semicolon form: 38;5;n or 38;2;r;g;b
+			n := 2
+			if params[i+1].Param(0) == 2 {
+				n = 4
+			}
+			for _, param := range params[i+1 : min(i+1+n, len(params))] {
+				group = append(group, param.Param(0))
+			}
+			i += n
+		}
+
+		c, kind, ok := sgrColor(group)
+		if !ok {
+			out = append(out, joinParams(group))
+			continue
+		}
+		if p == NoColor {
+			continue
+		}
+
+		c = p.Convert(c)
+		switch kind {
+		case 38:
+			out = append(out, c.sgr(false))
+		case 48:
+			out = append(out, c.sgr(true))
+		case 58:
+			out = append(out, c.underlineSGR())
+		}
+	}
+	return strings.Join(out, ";")
+}
+
+// sgrColor decodes the color set by an SGR attribute. kind is 38 for the
+// foreground, 48 for the background and 58 for the underline color.
+func sgrColor(group []int) (c Color, kind int, ok bool) {
+	v := group[0]
+	switch {
+	case v >= 30 && v <= 37:
+		return ANSIColor(uint8(v - 30)), 38, true
+	case v >= 90 && v <= 97:
+		return ANSIColor(uint8(v - 90 + 8)), 38, true
+	case v >= 40 && v <= 47:
+		return ANSIColor(uint8(v - 40)), 48, true
+	case v >= 100 && v <= 107:
+		return ANSIColor(uint8(v - 100 + 8)), 48, true
+	case v == 39 || v == 49 || v == 59:
+		return ColorDefault, v - 1, true
+	case v == 38 || v == 48 || v == 58:
+		switch {
+		case len(group) >= 3 && group[1] == 5:
+			return ANSI256Color(uint8(group[2])), v, true
+		case len(group) >= 6 && group[1] == 2:
+			// 38:2:<colorspace>:r:g:b
+			return RGB(uint8(group[3]), uint8(group[4]), uint8(group[5])), v, true
+		case len(group) >= 5 && group[1] == 2:
+			return RGB(uint8(group[2]), uint8(group[3]), uint8(group[4])), v, true
+		}
+	}
+	return ColorDefault, 0, false
+}
+
+// joinParams encodes colon separated sub-parameters; missing ones stay empty
+func joinParams(group []int) string {
+	parts := make([]string, len(group))
+	for i, v := range group {
+		if v >= 0 {
+			parts[i] = strconv.Itoa(v)
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+// ansiPalette holds the xterm default values of the 16 ANSI colors
+var ansiPalette = [16][3]uint8{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the component values of the 6x6x6 color cube (16-231)
+var cubeLevels = [6]uint8{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// ansi256To16 maps each palette entry to its closest ANSI color
+var ansi256To16 = func() (table [256]uint8) {
+	for i := range table {
+		if i < 16 {
+			table[i] = uint8(i)
+			continue
+		}
+		table[i] = nearestANSI(paletteRGB(uint8(i)))
+	}
+	return table
+}()
+
+// paletteRGB returns the xterm default value of palette entry n
+func paletteRGB(n uint8) (r, g, b uint8) {
+	switch {
+	case n < 16:
+		c := ansiPalette[n]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+	default:
+		v := 8 + 10*(n-232)
+		return v, v, v
+	}
+}
+
+// colorRGB returns the RGB value of any color, using the xterm palette for
+// ANSI and 256-color values. The default color counts as black.
+func colorRGB(c Color) (r, g, b uint8) {
+	switch {
+	case c.IsRGB():
+		return c.RGB()
+	case c.IsANSI(), c.Is256():
+		return paletteRGB(c.Index())
+	}
+	return 0, 0, 0
+}
+
+// nearestANSI returns the index of the ANSI color closest to r, g, b
+func nearestANSI(r, g, b uint8) uint8 {
+	best, bestDist := 0, -1
+	for i, c := range ansiPalette {
+		if d := colorDistance(r, g, b, c[0], c[1], c[2]); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return uint8(best)
+}
+
+// rgbTo256 returns the palette entry closest to r, g, b, choosing between the
+// nearest color cube entry and the nearest gray
+func rgbTo256(r, g, b uint8) uint8 {
+	level := func(v uint8) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		}
+		return (int(v) - 35) / 40
+	}
+	qr, qg, qb := level(r), level(g), level(b)
+	cube := 16 + 36*qr + 6*qg + qb
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIdx := 23
+	if avg < 238 {
+		grayIdx = max((avg-3)/10, 0)
+	}
+	gray := uint8(8 + 10*grayIdx)
+
+	cubeDist := colorDistance(r, g, b, cubeLevels[qr], cubeLevels[qg], cubeLevels[qb])
+	if colorDistance(r, g, b, gray, gray, gray) < cubeDist {
+		return uint8(232 + grayIdx)
+	}
+	return uint8(cube)
+}
+
+// colorDistance is a cheap perceptual distance between two colors, weighting
+// the components by the mean red value ("redmean")
+func colorDistance(r1, g1, b1, r2, g2, b2 uint8) int {
+	rmean := (int(r1) + int(r2)) / 2
+	dr := int(r1) - int(r2)
+	dg := int(g1) - int(g2)
+	db := int(b1) - int(b2)
+	return ((512+rmean)*dr*dr)>>8 + 4*dg*dg + ((767-rmean)*db*db)>>8
+}
@@ -0,0 +1,127 @@
+package engine
+
+import "testing"
+
+func TestDetectColorProfile(t *testing.T) {
+	tests := []struct {
+		name  string
+		isTTY bool
+		env   map[string]string
+		want  ColorProfile
+	}{
+		{"no color", true, map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, NoColor},
+		{"not a terminal", false, map[string]string{"COLORTERM": "truecolor"}, NoColor},
+		{"forced", false, map[string]string{"CLICOLOR_FORCE": "1", "COLORTERM": "truecolor"}, TrueColor},
+		{"force disabled", false, map[string]string{"CLICOLOR_FORCE": "0", "COLORTERM": "truecolor"}, NoColor},
+		{"colorterm", true, map[string]string{"COLORTERM": "24bit", "TERM": "xterm"}, TrueColor},
+		{"dumb", true, map[string]string{"TERM": "dumb"}, NoColor},
+		{"linux console", true, map[string]string{"TERM": "linux"}, ANSI},
+		{"vt100", true, map[string]string{"TERM": "vt100"}, ANSI},
+		{"kitty", true, map[string]string{"TERM": "xterm-kitty"}, TrueColor},
+		{"256color", true, map[string]string{"TERM": "xterm-256color"}, ANSI256},
+		{"iterm", true, map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app"}, TrueColor},
+		{"apple terminal", true, map[string]string{"TERM": "xterm", "TERM_PROGRAM": "Apple_Terminal"}, ANSI256},
+		{"xterm", true, map[string]string{"TERM": "xterm"}, ANSI},
+	}
+
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := detectColorProfile(tt.isTTY, getenv); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRGBTo256(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    uint8
+	}{
+		{0, 0, 0, 16},
+		{255, 0, 0, 196},
+		{95, 135, 175, 67},
+		{255, 255, 255, 231},
+		// Grays pick the grayscale ramp when it is closer than the cube
+		{128, 128, 128, 244},
+		{8, 8, 8, 232},
+		{238, 238, 238, 255},
+	}
+
+	for _, tt := range tests {
+		if got := rgbTo256(tt.r, tt.g, tt.b); got != tt.want {
+			t.Errorf("rgbTo256(%d, %d, %d) = %d, want %d", tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNearestANSI(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    uint8
+	}{
+		{0, 0, 0, 0},
+		{205, 0, 0, 1},
+		{255, 0, 0, 9},
+		{0, 0, 230, 4},
+		{128, 128, 128, 8},
+		{250, 250, 250, 15},
+	}
+
+	for _, tt := range tests {
+		if got := nearestANSI(tt.r, tt.g, tt.b); got != tt.want {
+			t.Errorf("nearestANSI(%d, %d, %d) = %d, want %d", tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		profile ColorProfile
+		in      Color
+		want    Color
+	}{
+		{TrueColor, RGB(1, 2, 3), RGB(1, 2, 3)},
+		{ANSI256, RGB(255, 0, 0), ANSI256Color(196)},
+		{ANSI256, ColorRed, ColorRed},
+		{ANSI, RGB(255, 0, 0), ColorBrightRed},
+		{ANSI, ANSI256Color(196), ColorBrightRed},
+		{ANSI, ColorDefault, ColorDefault},
+		{NoColor, ColorRed, ColorDefault},
+	}
+
+	for _, tt := range tests {
+		if got := tt.profile.Convert(tt.in); got != tt.want {
+			t.Errorf("%v.Convert(%v) = %v, want %v", tt.profile, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConvertString(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile ColorProfile
+		in      string
+		want    string
+	}{
+		{"true color keeps everything", TrueColor, "\x1b[38;2;1;2;3mx", "\x1b[38;2;1;2;3mx"},
+		{"rgb to 256", ANSI256, "\x1b[38;2;255;0;0mx", "\x1b[38;5;196mx"},
+		{"gray to 256", ANSI256, "\x1b[48;2;128;128;128mx", "\x1b[48;5;244mx"},
+		{"attributes kept", ANSI, "\x1b[1;38;2;255;0;0;48;5;196;4mx", "\x1b[1;91;101;4mx"},
+		{"colon rgb", ANSI256, "\x1b[38:2::255:0:0mx", "\x1b[38;5;196mx"},
+		{"colon rgb without color space", ANSI256, "\x1b[48:2:255:0:0mx", "\x1b[48;5;196mx"},
+		{"colon 256", ANSI, "\x1b[38:5:196mx", "\x1b[91mx"},
+		{"underline style and color", ANSI256, "\x1b[4:3;58:2::255:0:0mx", "\x1b[4:3;58;5;196mx"},
+		{"underline color to ansi", ANSI, "\x1b[58;2;255;0;0mx", "\x1b[58;5;9mx"},
+		{"default colors", ANSI, "\x1b[39;49;59mx", "\x1b[39;49;59mx"},
+		{"no color drops colors", NoColor, "\x1b[1;31;48;5;4mx\x1b[0m", "\x1b[1mx\x1b[0m"},
+		{"no color drops empty sgr", NoColor, "\x1b[31mx", "x"},
+		{"reset without params", ANSI, "\x1b[mx", "\x1b[mx"},
+		{"other sequences", ANSI, "\x1b[2J\x1b[?25l\x1b[1;1Hx", "\x1b[2J\x1b[?25l\x1b[1;1Hx"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.profile.ConvertString(tt.in); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
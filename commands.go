@@ -0,0 +1,74 @@
+package engine
+
+import "fmt"
+
+// BatchMsg is returned by the command created with Batch. Program.Run runs
+// every command concurrently and delivers each result as its own message.
+type BatchMsg []Cmd
+
+// SequenceMsg is returned by the command created with Sequence. Program.Run
+// runs the commands one after another, delivering each result before starting
+// the next command.
+type SequenceMsg []Cmd
+
+// Batch combines commands that run concurrently. Nil commands are dropped;
+// Batch returns nil when no command is left.
+func Batch(cmds ...Cmd) Cmd {
+	valid := filterCmds(cmds)
+	switch len(valid) {
+	case 0:
+		return nil
+	case 1:
+		return valid[0]
+	}
+	return func() Msg {
+		return BatchMsg(valid)
+	}
+}
+
+// Sequence combines commands that run in order. Nil commands are dropped;
+// Sequence returns nil when no command is left.
+func Sequence(cmds ...Cmd) Cmd {
+	valid := filterCmds(cmds)
+	switch len(valid) {
+	case 0:
+		return nil
+	case 1:
+		return valid[0]
+	}
+	return func() Msg {
+		return SequenceMsg(valid)
+	}
+}
+
+// filterCmds returns cmds without nil entries
+func filterCmds(cmds []Cmd) []Cmd {
+	var valid []Cmd
+	for _, cmd := range cmds {
+		if cmd != nil {
+			valid = append(valid, cmd)
+		}
+	}
+	return valid
+}
+
+// printLineMsg is returned by the commands created with Println and Printf
+type printLineMsg struct {
+	text string
+}
+
+// Println prints a line above the view of an inline program. The line stays in
+// the terminal's scrollback when the view is redrawn or the program exits.
+// Nothing is printed while the alternate screen is active.
+func Println(args ...any) Cmd {
+	return func() Msg {
+		return printLineMsg{text: fmt.Sprint(args...)}
+	}
+}
+
+// Printf is like Println with a format string
+func Printf(template string, args ...any) Cmd {
+	return func() Msg {
+		return printLineMsg{text: fmt.Sprintf(template, args...)}
+	}
+}
@@ -0,0 +1,127 @@
+package engine_test
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+type step string
+
+// emit returns a command delivering s after delay
+func emit(s string, delay time.Duration) engine.Cmd {
+	return func() engine.Msg {
+		time.Sleep(delay)
+		return step(s)
+	}
+}
+
+// stepModel runs cmd on startup and records the steps it receives
+type stepModel struct {
+	cmd   engine.Cmd
+	steps []string
+}
+
+func (m stepModel) Init() engine.Msg { return nil }
+
+func (m stepModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	switch msg := msg.(type) {
+	case nil:
+		return m, m.cmd
+	case step:
+		m.steps = append(m.steps, string(msg))
+	}
+	return m, nil
+}
+
+func (m stepModel) View() string { return strconv.Itoa(len(m.steps)) }
+
+func TestBatchAndSequence(t *testing.T) {
+	const slow = 20 * time.Millisecond
+
+	tests := []struct {
+		name string
+		cmd  engine.Cmd
+		// want lists the steps in groups: groups arrive in order, the steps of
+		// a group in any order
+		want [][]string
+	}{
+		{
+			name: "sequence waits for each command",
+			cmd:  engine.Sequence(emit("a", slow), emit("b", 0), emit("c", 0)),
+			want: [][]string{{"a"}, {"b"}, {"c"}},
+		},
+		{
+			name: "batch runs concurrently",
+			cmd:  engine.Batch(emit("a", slow), emit("b", 0)),
+			want: [][]string{{"b"}, {"a"}},
+		},
+		{
+			name: "nil commands are dropped",
+			cmd:  engine.Sequence(nil, emit("a", 0), nil, engine.Batch(nil, emit("b", 0)), nil),
+			want: [][]string{{"a"}, {"b"}},
+		},
+		{
+			name: "sequence waits for a nested batch",
+			cmd: engine.Sequence(
+				emit("a", 0),
+				engine.Batch(emit("b", slow), emit("c", 0)),
+				emit("d", 0),
+			),
+			want: [][]string{{"a"}, {"b", "c"}, {"d"}},
+		},
+		{
+			name: "nested sequences keep their order",
+			cmd: engine.Sequence(
+				engine.Sequence(emit("a", slow), emit("b", 0)),
+				engine.Sequence(emit("c", 0), engine.Sequence(emit("d", slow), emit("e", 0))),
+			),
+			want: [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}},
+		},
+		{
+			name: "sequences in a batch",
+			cmd: engine.Batch(
+				engine.Sequence(emit("a", 0), emit("b", 2*slow)),
+				engine.Sequence(emit("c", slow), emit("d", 2*slow)),
+			),
+			want: [][]string{{"a"}, {"c"}, {"b"}, {"d"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var total int
+			for _, group := range tt.want {
+				total += len(group)
+			}
+
+			tm := enginetest.NewTestModel(t, stepModel{cmd: tt.cmd})
+			tm.WaitFor(func(s *enginetest.Screen) bool { return s.Line(0) == strconv.Itoa(total) })
+			tm.Quit()
+
+			got := tm.FinalModel().(stepModel).steps
+			rest := got
+			for _, group := range tt.want {
+				head := slices.Clone(rest[:len(group)])
+				slices.Sort(head)
+				if !slices.Equal(head, slices.Sorted(slices.Values(group))) {
+					t.Fatalf("steps = %v, want the groups %v in order", got, tt.want)
+				}
+				rest = rest[len(group):]
+			}
+		})
+	}
+}
+
+func TestBatchAndSequenceWithoutCommands(t *testing.T) {
+	if engine.Batch() != nil || engine.Batch(nil, nil) != nil {
+		t.Error("Batch without commands is not nil")
+	}
+	if engine.Sequence() != nil || engine.Sequence(nil) != nil {
+		t.Error("Sequence without commands is not nil")
+	}
+}
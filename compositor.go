@@ -4,6 +4,9 @@ type Layer struct {
 	Buffer *PixelBuffer
 	ZIndex int
 	Alpha  float32 // 0.0 (transparent) to 1.0 (opaque)
+	// Transparent skips the pixels never drawn (the zero Pixel), so a sparse
+	// layer such as a BrailleCanvas overlay shows the layers below it
+	Transparent bool
 }
 
 type Compositor struct {
@@ -15,15 +18,21 @@ func (c *Compositor) AddLayer(layer *Layer) {
 	// Sort layers by ZIndex --> Look for optimized sorting algorithm
 }
 
+// Composite flattens the layers into a buffer the size of the first one.
+// Pixels never drawn are only skipped on Transparent layers.
 func (c *Compositor) Composite() *PixelBuffer {
 	result := NewPixelBuffer(c.Layers[0].Buffer.Width, c.Layers[0].Buffer.Height)
 
 	for _, layer := range c.Layers {
-		for y := 0; y < layer.Buffer.Height; y++ {
-			for x := 0; x < layer.Buffer.Width; x++ {
+		for y := 0; y < min(layer.Buffer.Height, result.Height); y++ {
+			for x := 0; x < min(layer.Buffer.Width, result.Width); x++ {
 				pixel := layer.Buffer.Data[y][x]
-				if layer.Alpha > 0.5 {
-					result.Data[y][x] = pixel
+				if layer.Transparent && pixel == (Pixel{}) {
+					continue
+				}
+				if layer.Alpha > 0.5 && !pixel.IsContinuation() {
+					// SetPixel recreates the continuation cells of wide glyphs
+					result.SetPixel(x, y, pixel)
 				}
 			}
 		}
@@ -0,0 +1,31 @@
+package engine
+
+import "testing"
+
+func TestCompositeTransparentLayer(t *testing.T) {
+	background := NewPixelBuffer(3, 1)
+	background.FillRect(0, 0, 3, 1, Pixel{Char: '#', BG: ColorBlue})
+
+	plot := NewBrailleCanvas(1, 1)
+	plot.Set(0, 0)
+	overlay := NewPixelBuffer(3, 1)
+	plot.DrawTo(overlay, 1, 0)
+
+	for _, transparent := range []bool{false, true} {
+		c := &Compositor{}
+		c.AddLayer(&Layer{Buffer: background, Alpha: 1})
+		c.AddLayer(&Layer{Buffer: overlay, Alpha: 1, Transparent: transparent})
+		row := c.Composite().Data[0]
+
+		want := Pixel{}
+		if transparent {
+			want = background.Data[0][0]
+		}
+		if row[0] != want || row[2] != want {
+			t.Errorf("transparent=%v: undrawn cells = %+v, %+v, want %+v", transparent, row[0], row[2], want)
+		}
+		if row[1].Char != '⠁' {
+			t.Errorf("transparent=%v: dot cell = %+v", transparent, row[1])
+		}
+	}
+}
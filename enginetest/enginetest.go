@@ -0,0 +1,336 @@
+// Package enginetest runs engine Models headlessly for unit tests.
+//
+// A TestModel runs a Model (or PixelModel, CanvasModel) in a real
+// engine.Program whose input is a pipe and whose output is an in-memory
+// Screen, so every frame goes through the renderer exactly like it would on a
+// terminal:
+//
+//	tm := enginetest.NewTestModel(t, newGame(), enginetest.WithInitialSize(40, 10))
+//	tm.Type("w")
+//	tm.WaitFor(func(s *enginetest.Screen) bool { return s.Contains("Score: 1") })
+//	tm.Quit()
+//	enginetest.RequireEqualGolden(t, []byte(tm.FinalScreen().String()))
+package enginetest
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+)
+
+const (
+	defaultWidth    = 80
+	defaultHeight   = 24
+	defaultTimeout  = time.Second
+	defaultInterval = 10 * time.Millisecond
+)
+
+// TestModel runs a Model in a Program drawing onto a virtual Screen
+type TestModel struct {
+	tb      testing.TB
+	program *engine.Program
+	screen  *Screen
+	in      io.WriteCloser
+	done    chan struct{}
+	err     error
+
+	mtx   sync.Mutex
+	model engine.Model
+
+	width   int
+	height  int
+	profile engine.ColorProfile
+	opts    []engine.ProgramOption
+}
+
+// Option configures a TestModel
+type Option func(*TestModel)
+
+// WithInitialSize sets the size of the virtual terminal sent in the first SizeMsg
+func WithInitialSize(width, height int) Option {
+	return func(tm *TestModel) {
+		tm.width = width
+		tm.height = height
+	}
+}
+
+// WithColorProfile converts the colors of every frame for profile, the way the
+// renderer does on a terminal with that profile. Frames keep all their colors
+// by default.
+func WithColorProfile(profile engine.ColorProfile) Option {
+	return func(tm *TestModel) {
+		tm.profile = profile
+	}
+}
+
+// WithProgramOptions passes extra options to the Program, e.g.
+// engine.WithAltScreen() or engine.WithOnDemandRendering()
+func WithProgramOptions(opts ...engine.ProgramOption) Option {
+	return func(tm *TestModel) {
+		tm.opts = append(tm.opts, opts...)
+	}
+}
+
+// NewTestModel starts running m in a Program. The model receives its Init
+// message and an initial SizeMsg like it would on a terminal. PixelModel and
+// CanvasModel values are drawn with the pixel renderer.
+func NewTestModel(tb testing.TB, m engine.Model, opts ...Option) *TestModel {
+	tb.Helper()
+
+	tm := &TestModel{
+		tb:      tb,
+		model:   m,
+		done:    make(chan struct{}),
+		width:   defaultWidth,
+		height:  defaultHeight,
+		profile: engine.TrueColor,
+	}
+
+	for _, opt := range opts {
+		opt(tm)
+	}
+
+	tm.screen = NewScreen(tm.width, tm.height)
+
+	// A pipe backed by a file lets the Program cancel pending reads when it
+	// releases the terminal, like it does with a real tty
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		tb.Fatalf("failed to create input pipe: %v", err)
+	}
+	tm.in = inW
+
+	programOpts := []engine.ProgramOption{
+		engine.WithInput(inR),
+		engine.WithOutput(tm.screen),
+		engine.WithWindowSize(tm.width, tm.height),
+		engine.WithColorProfile(tm.profile),
+		engine.WithoutSignalHandler(),
+	}
+	switch m.(type) {
+	case engine.PixelModel, engine.CanvasModel:
+		programOpts = append(programOpts, engine.WithPixelRenderer())
+	}
+	tm.program = engine.NewProgram(tm.wrap(m), append(programOpts, tm.opts...)...)
+
+	go func() {
+		tm.err = tm.program.Run()
+
+		var panicErr *engine.ProgramPanicError
+		if errors.As(tm.err, &panicErr) {
+			tb.Errorf("model panicked: %v\n%s", panicErr.Value, panicErr.Stack)
+		}
+
+		close(tm.done)
+		_ = inR.Close()
+	}()
+
+	tb.Cleanup(func() {
+		tm.program.Kill()
+		<-tm.done
+		_ = inW.Close()
+	})
+
+	return tm
+}
+
+// Send delivers msg to the model. It is a no-op once the program has exited.
+func (tm *TestModel) Send(msg engine.Msg) {
+	tm.program.Send(msg)
+}
+
+// Type writes s to the program input, where it is decoded like keys typed on
+// a terminal: "q" is a KeyMsg, "\x1b[A" the up arrow and "\x11" ctrl+q
+func (tm *TestModel) Type(s string) {
+	tm.tb.Helper()
+
+	if _, err := io.WriteString(tm.in, s); err != nil && !tm.finished() {
+		tm.tb.Fatalf("failed to write input: %v", err)
+	}
+}
+
+// finished reports whether the program has exited
+func (tm *TestModel) finished() bool {
+	select {
+	case <-tm.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Resize resizes the virtual terminal and sends a SizeMsg
+func (tm *TestModel) Resize(width, height int) {
+	tm.screen.Resize(width, height)
+	tm.Send(engine.SizeMsg{Width: width, Height: height})
+}
+
+// Tick sends a TickMsg stamped with the current time
+func (tm *TestModel) Tick() {
+	tm.Send(engine.TickMsg{Time: time.Now()})
+}
+
+// Quit asks the program to exit and waits for it to stop
+func (tm *TestModel) Quit() {
+	tm.tb.Helper()
+	tm.program.Quit()
+	tm.WaitFinished()
+}
+
+// Model returns the latest model
+func (tm *TestModel) Model() engine.Model {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+	return tm.model
+}
+
+// Program returns the Program running the model
+func (tm *TestModel) Program() *engine.Program {
+	return tm.program
+}
+
+// Screen returns a snapshot of the virtual screen. Frames are written whole,
+// so the snapshot never shows a partly drawn frame.
+func (tm *TestModel) Screen() *Screen {
+	return tm.screen.Snapshot()
+}
+
+// WaitOption configures WaitFor and WaitFinished
+type WaitOption func(*waitConfig)
+
+type waitConfig struct {
+	timeout  time.Duration
+	interval time.Duration
+}
+
+// WithTimeout sets how long to wait before failing the test (default 1s)
+func WithTimeout(d time.Duration) WaitOption {
+	return func(c *waitConfig) {
+		c.timeout = d
+	}
+}
+
+// WithCheckInterval sets how often the condition is polled (default 10ms)
+func WithCheckInterval(d time.Duration) WaitOption {
+	return func(c *waitConfig) {
+		c.interval = d
+	}
+}
+
+func newWaitConfig(opts []WaitOption) waitConfig {
+	c := waitConfig{timeout: defaultTimeout, interval: defaultInterval}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WaitFor polls cond against snapshots of the screen until it returns true,
+// failing the test if it does not within the timeout
+func (tm *TestModel) WaitFor(cond func(*Screen) bool, opts ...WaitOption) {
+	tm.tb.Helper()
+
+	c := newWaitConfig(opts)
+	deadline := time.Now().Add(c.timeout)
+
+	for {
+		screen := tm.Screen()
+		if cond(screen) {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			tm.tb.Fatalf("condition not met after %s, screen:\n%s", c.timeout, screen.String())
+			return
+		}
+		time.Sleep(c.interval)
+	}
+}
+
+// WaitFinished waits for the program to exit, failing the test on timeout
+func (tm *TestModel) WaitFinished(opts ...WaitOption) {
+	tm.tb.Helper()
+
+	c := newWaitConfig(opts)
+	select {
+	case <-tm.done:
+	case <-time.After(c.timeout):
+		tm.tb.Fatalf("program did not exit after %s", c.timeout)
+	}
+}
+
+// FinalModel waits for the program to exit and returns the last model
+func (tm *TestModel) FinalModel(opts ...WaitOption) engine.Model {
+	tm.tb.Helper()
+	tm.WaitFinished(opts...)
+	return tm.Model()
+}
+
+// FinalScreen waits for the program to exit and returns the screen as the
+// user would see it once the terminal has been restored
+func (tm *TestModel) FinalScreen(opts ...WaitOption) *Screen {
+	tm.tb.Helper()
+	tm.WaitFinished(opts...)
+	return tm.Screen()
+}
+
+// FinalError waits for the program to exit and returns the error of Run, e.g.
+// engine.ErrInterrupted after an InterruptMsg
+func (tm *TestModel) FinalError(opts ...WaitOption) error {
+	tm.tb.Helper()
+	tm.WaitFinished(opts...)
+	return tm.err
+}
+
+// setModel records m as the latest model
+func (tm *TestModel) setModel(m engine.Model) {
+	tm.mtx.Lock()
+	defer tm.mtx.Unlock()
+	tm.model = m
+}
+
+// wrap returns a recorder for m that keeps the PixelModel or CanvasModel
+// interface of m, so the Program renders it the same way
+func (tm *TestModel) wrap(m engine.Model) engine.Model {
+	r := recorder{Model: m, tm: tm}
+	switch m.(type) {
+	case engine.PixelModel:
+		return pixelRecorder{r}
+	case engine.CanvasModel:
+		return canvasRecorder{r}
+	}
+	return r
+}
+
+// recorder wraps the model under test to keep the latest model readable
+// while the program runs
+type recorder struct {
+	engine.Model
+	tm *TestModel
+}
+
+// Update forwards msg to the model and records the result
+func (r recorder) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	m, cmd := r.Model.Update(msg)
+	r.tm.setModel(m)
+	return r.tm.wrap(m), cmd
+}
+
+type pixelRecorder struct{ recorder }
+
+// PixelView returns the pixel buffer of the wrapped model
+func (r pixelRecorder) PixelView() *engine.PixelBuffer {
+	return r.Model.(engine.PixelModel).PixelView()
+}
+
+type canvasRecorder struct{ recorder }
+
+// CanvasView returns the canvas of the wrapped model
+func (r canvasRecorder) CanvasView() *engine.Canvas {
+	return r.Model.(engine.CanvasModel).CanvasView()
+}
@@ -0,0 +1,213 @@
+package enginetest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+)
+
+// counter counts key presses and quits on "q"
+type counter struct {
+	count int
+	keys  []string
+	size  engine.SizeMsg
+	// other lists the types of the other messages received
+	other []string
+}
+
+func (m counter) Init() engine.Msg { return nil }
+
+func (m counter) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	switch msg := msg.(type) {
+	case engine.SizeMsg:
+		m.size = msg
+	case engine.KeyMsg:
+		m.keys = append(m.keys, msg.String())
+		switch msg.String() {
+		case "q":
+			return m, engine.Quit
+		case "up":
+			m.count++
+		case "p":
+			return m, engine.Println("pressed p")
+		case "x":
+			panic("boom")
+		}
+	case nil:
+	default:
+		m.other = append(m.other, fmt.Sprintf("%T", msg))
+	}
+	return m, nil
+}
+
+func (m counter) View() string {
+	return fmt.Sprintf("Count: %d\nSize: %dx%d", m.count, m.size.Width, m.size.Height)
+}
+
+func TestTypeDecodesInput(t *testing.T) {
+	tm := NewTestModel(t, counter{}, WithInitialSize(20, 5))
+
+	tm.Type("\x1b[A\x1b[A\x11a")
+	tm.WaitFor(func(s *Screen) bool { return s.Contains("Count: 2") })
+	tm.Type("q")
+
+	m := tm.FinalModel().(counter)
+	if want := []string{"up", "up", "ctrl+q", "a", "q"}; strings.Join(m.keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys = %v, want %v", m.keys, want)
+	}
+	if err := tm.FinalError(); err != nil {
+		t.Errorf("Run returned %v", err)
+	}
+}
+
+func TestInitialSizeAndResize(t *testing.T) {
+	tm := NewTestModel(t, counter{}, WithInitialSize(30, 6))
+	tm.WaitFor(func(s *Screen) bool { return s.Contains("Size: 30x6") })
+
+	tm.Resize(40, 8)
+	tm.WaitFor(func(s *Screen) bool { return s.Contains("Size: 40x8") })
+
+	if w, h := tm.Screen().Size(); w != 40 || h != 8 {
+		t.Errorf("screen size = %dx%d, want 40x8", w, h)
+	}
+	tm.Quit()
+}
+
+func TestPrintlnGoesThroughTheProgram(t *testing.T) {
+	tm := NewTestModel(t, counter{}, WithInitialSize(20, 6))
+
+	tm.Type("p")
+	tm.WaitFor(func(s *Screen) bool { return s.Line(0) == "pressed p" && s.Line(1) == "Count: 0" })
+	tm.Quit()
+
+	if other := tm.FinalModel().(counter).other; len(other) > 0 {
+		t.Errorf("the model received %v", other)
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	tm := NewTestModel(t, counter{})
+	tm.Send(engine.InterruptMsg{})
+
+	if err := tm.FinalError(); !errors.Is(err, engine.ErrInterrupted) {
+		t.Errorf("Run returned %v, want ErrInterrupted", err)
+	}
+}
+
+func TestSuspendResumes(t *testing.T) {
+	resumed := make(chan struct{})
+	tm := NewTestModel(t, resumeModel{resumed: resumed})
+
+	tm.Send(engine.SuspendMsg{})
+	select {
+	case <-resumed:
+	case <-time.After(time.Second):
+		t.Fatal("no ResumeMsg after suspending")
+	}
+	tm.Quit()
+}
+
+// resumeModel closes resumed when it receives a ResumeMsg
+type resumeModel struct {
+	resumed chan struct{}
+}
+
+func (m resumeModel) Init() engine.Msg { return nil }
+
+func (m resumeModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	if _, ok := msg.(engine.ResumeMsg); ok {
+		close(m.resumed)
+	}
+	return m, nil
+}
+
+func (m resumeModel) View() string { return "running" }
+
+// recordingTB records the errors reported by a TestModel
+type recordingTB struct {
+	testing.TB
+	mtx  sync.Mutex
+	errs []string
+}
+
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func TestPanicIsReported(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	tm := NewTestModel(tb, counter{})
+	tm.Type("x")
+
+	var panicErr *engine.ProgramPanicError
+	if err := tm.FinalError(); !errors.As(err, &panicErr) {
+		t.Fatalf("Run returned %v, want a ProgramPanicError", err)
+	}
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+	if len(tb.errs) != 1 || !strings.Contains(tb.errs[0], "boom") {
+		t.Errorf("reported errors = %q", tb.errs)
+	}
+}
+
+func TestAltScreen(t *testing.T) {
+	tm := NewTestModel(t, counter{}, WithInitialSize(20, 4), WithProgramOptions(engine.WithAltScreen()))
+
+	tm.Type("\x1b[A")
+	tm.WaitFor(func(s *Screen) bool { return s.Line(0) == "Count: 1" })
+	RequireEqualScreen(t, tm.Screen())
+	tm.Quit()
+
+	// Leaving the alternate screen brings back the empty main screen
+	final := tm.FinalScreen()
+	if final.AltScreen() || final.String() != "" {
+		t.Errorf("final screen: alt=%v %q, want the empty main screen", final.AltScreen(), final.String())
+	}
+}
+
+// board is a PixelModel drawing a row of letters
+type board struct{}
+
+func (board) Init() engine.Msg { return nil }
+
+func (m board) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	if _, ok := msg.(engine.KeyMsg); ok {
+		return m, engine.Quit
+	}
+	return m, nil
+}
+
+func (board) View() string { return "" }
+
+func (board) PixelView() *engine.PixelBuffer {
+	pb := engine.NewPixelBuffer(6, 2)
+	pb.SetString(0, 0, "pixel", engine.Pixel{FG: engine.ColorRed})
+	pb.SetString(0, 1, "世界", engine.Pixel{})
+	return pb
+}
+
+func TestPixelModel(t *testing.T) {
+	for _, alt := range []bool{false, true} {
+		t.Run(fmt.Sprintf("alt=%v", alt), func(t *testing.T) {
+			var opts []Option
+			if alt {
+				opts = append(opts, WithProgramOptions(engine.WithAltScreen()))
+			}
+			tm := NewTestModel(t, board{}, append(opts, WithInitialSize(10, 3))...)
+
+			tm.WaitFor(func(s *Screen) bool { return s.Line(1) == "世界" })
+			if c := tm.Screen().Cell(0, 0); c.Content != "p" || !strings.Contains(c.Style, "31") {
+				t.Errorf("cell 0,0 = %+v, want a red p", c)
+			}
+			tm.Type("q")
+			tm.WaitFinished()
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package enginetest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update .golden files")
+
+// GoldenPath returns the golden file used by the running test:
+// testdata/<TestName>.golden, with subtest separators kept as directories
+func GoldenPath(tb testing.TB) string {
+	return filepath.Join("testdata", filepath.FromSlash(tb.Name())+".golden")
+}
+
+// RequireEqualGolden compares out with the test's golden file and fails the
+// test on mismatch. Run the tests with -update to (re)write the golden file.
+func RequireEqualGolden(tb testing.TB, out []byte) {
+	tb.Helper()
+
+	path := GoldenPath(tb)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, out, 0o600); err != nil {
+			tb.Fatalf("failed to write golden file %s: %v", path, err)
+		}
+		return
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("failed to read golden file %s (run with -update to create it): %v", path, err)
+	}
+
+	golden = bytes.ReplaceAll(golden, []byte("\r\n"), []byte("\n"))
+	if !bytes.Equal(golden, out) {
+		tb.Fatalf("output does not match golden file %s\n%s", path, diffLines(string(golden), string(out)))
+	}
+}
+
+// RequireEqualScreen compares the screen text with the test's golden file
+func RequireEqualScreen(tb testing.TB, s *Screen) {
+	tb.Helper()
+	RequireEqualGolden(tb, []byte(s.String()+"\n"))
+}
+
+// diffLines lists the lines that differ between want and got
+func diffLines(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+
+	var sb strings.Builder
+	for i := 0; i < max(len(wantLines), len(gotLines)); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&sb, "line %d:\n  want: %q\n  got:  %q\n", i+1, w, g)
+		}
+	}
+	return sb.String()
+}
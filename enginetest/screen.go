@@ -0,0 +1,541 @@
+package enginetest
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Cell is a single character cell of a Screen
+type Cell struct {
+	// Content is the grapheme drawn in the cell. It is empty for the
+	// continuation cell to the right of a wide character.
+	Content string
+	// Style holds the SGR parameters active when the cell was drawn,
+	// e.g. "38;5;1;48;5;4". It is empty for the default style.
+	Style string
+}
+
+// Screen is an in-memory terminal. It implements io.Writer and interprets the
+// subset of ANSI sequences emitted by the engine renderers: printable text,
+// CR/LF/BS, cursor movement, erase in line/display, scroll regions, SGR and
+// the alternate screen (mode 1049).
+type Screen struct {
+	mtx sync.Mutex
+
+	width   int
+	height  int
+	cells   [][]Cell
+	cursorX int
+	cursorY int
+	style   []string
+
+	// top and bottom are the rows of the scroll region (DECSTBM), inclusive
+	top    int
+	bottom int
+
+	// main holds the main screen and its cursor while the alternate screen is active
+	main    [][]Cell
+	mainX   int
+	mainY   int
+	altMode bool
+
+	state  byte
+	parser *ansi.Parser
+}
+
+// NewScreen creates a blank screen of the given size
+func NewScreen(width, height int) *Screen {
+	s := &Screen{parser: ansi.NewParser()}
+	s.resize(width, height)
+	return s
+}
+
+// Size returns the screen width and height
+func (s *Screen) Size() (int, int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.width, s.height
+}
+
+// Cursor returns the 0-based cursor position
+func (s *Screen) Cursor() (int, int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.cursorX, s.cursorY
+}
+
+// AltScreen reports whether the alternate screen is active
+func (s *Screen) AltScreen() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.altMode
+}
+
+// Resize changes the screen size, keeping the overlapping content
+func (s *Screen) Resize(width, height int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.resize(width, height)
+}
+
+// Clear blanks every cell and moves the cursor home
+func (s *Screen) Clear() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.eraseDisplay(2)
+	s.cursorX, s.cursorY = 0, 0
+}
+
+// Snapshot returns a copy of the screen that later writes do not change
+func (s *Screen) Snapshot() *Screen {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	c := &Screen{
+		width:   s.width,
+		height:  s.height,
+		cells:   make([][]Cell, len(s.cells)),
+		cursorX: s.cursorX,
+		cursorY: s.cursorY,
+		style:   slices.Clone(s.style),
+		top:     s.top,
+		bottom:  s.bottom,
+		mainX:   s.mainX,
+		mainY:   s.mainY,
+		altMode: s.altMode,
+		parser:  ansi.NewParser(),
+	}
+	for y, row := range s.cells {
+		c.cells[y] = slices.Clone(row)
+	}
+	if s.main != nil {
+		c.main = make([][]Cell, len(s.main))
+		for y, row := range s.main {
+			c.main[y] = slices.Clone(row)
+		}
+	}
+	return c
+}
+
+// Cell returns the cell at x, y or a blank cell when out of bounds
+func (s *Screen) Cell(x, y int) Cell {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if x < 0 || x >= s.width || y < 0 || y >= s.height {
+		return Cell{Content: " "}
+	}
+	return s.cells[y][x]
+}
+
+// Cells returns a copy of the cell grid indexed as [y][x]
+func (s *Screen) Cells() [][]Cell {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	cells := make([][]Cell, len(s.cells))
+	for y, row := range s.cells {
+		cells[y] = append([]Cell(nil), row...)
+	}
+	return cells
+}
+
+// Line returns the text of row y with trailing spaces removed
+func (s *Screen) Line(y int) string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.line(y)
+}
+
+// Lines returns the text of every row with trailing spaces removed
+func (s *Screen) Lines() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	lines := make([]string, s.height)
+	for y := range lines {
+		lines[y] = s.line(y)
+	}
+	return lines
+}
+
+// String returns the screen text, one line per row, without trailing blank lines
+func (s *Screen) String() string {
+	lines := s.Lines()
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Contains reports whether text appears on any row of the screen
+func (s *Screen) Contains(text string) bool {
+	for _, line := range s.Lines() {
+		if strings.Contains(line, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Write interprets p as terminal output
+func (s *Screen) Write(p []byte) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	data := p
+	for len(data) > 0 {
+		seq, width, n, newState := ansi.DecodeSequence(data, s.state, s.parser)
+		s.state = newState
+		data = data[n:]
+
+		switch {
+		case width > 0:
+			s.print(string(seq), width)
+		case len(seq) == 1:
+			s.control(seq[0])
+		case ansi.HasCsiPrefix(seq):
+			s.csi(ansi.Cmd(s.parser.Command()), s.parser.Params())
+		}
+	}
+
+	return len(p), nil
+}
+
+// line renders row y as text; the caller holds the lock
+func (s *Screen) line(y int) string {
+	if y < 0 || y >= s.height {
+		return ""
+	}
+	var sb strings.Builder
+	for _, c := range s.cells[y] {
+		sb.WriteString(c.Content)
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+func (s *Screen) resize(width, height int) {
+	s.cells = resizeCells(s.cells, s.width, s.height, width, height)
+	if s.main != nil {
+		s.main = resizeCells(s.main, s.width, s.height, width, height)
+		s.mainX = min(s.mainX, max(width-1, 0))
+		s.mainY = min(s.mainY, max(height-1, 0))
+	}
+
+	s.width, s.height = width, height
+	s.top, s.bottom = 0, max(height-1, 0)
+	s.cursorX = min(s.cursorX, max(width-1, 0))
+	s.cursorY = min(s.cursorY, max(height-1, 0))
+}
+
+// resizeCells returns a width x height copy of cells, which is w x h, keeping
+// the overlapping content
+func resizeCells(cells [][]Cell, w, h, width, height int) [][]Cell {
+	resized := make([][]Cell, height)
+	for y := range resized {
+		resized[y] = make([]Cell, width)
+		for x := range resized[y] {
+			if y < h && x < w {
+				resized[y][x] = cells[y][x]
+			} else {
+				resized[y][x] = Cell{Content: " "}
+			}
+		}
+	}
+	return resized
+}
+
+// setAltScreen switches to a blank alternate screen, saving the main screen
+// and cursor, or back to the saved main screen
+func (s *Screen) setAltScreen(on bool) {
+	if on == s.altMode {
+		return
+	}
+	s.altMode = on
+
+	if on {
+		s.main, s.mainX, s.mainY = s.cells, s.cursorX, s.cursorY
+		s.cells = make([][]Cell, s.height)
+		for y := range s.cells {
+			s.cells[y] = blankRow(s.width)
+		}
+		return
+	}
+
+	s.cells, s.cursorX, s.cursorY = s.main, s.mainX, s.mainY
+	s.main = nil
+}
+
+func (s *Screen) print(content string, width int) {
+	if s.cursorX+width > s.width {
+		s.cursorX = 0
+		s.lineFeed()
+	}
+	if s.cursorY >= s.height || s.cursorX >= s.width {
+		return
+	}
+
+	style := strings.Join(s.style, ";")
+	s.cells[s.cursorY][s.cursorX] = Cell{Content: content, Style: style}
+	for i := 1; i < width; i++ {
+		s.cells[s.cursorY][s.cursorX+i] = Cell{Style: style}
+	}
+	s.cursorX += width
+}
+
+func (s *Screen) control(b byte) {
+	switch b {
+	case '\r':
+		s.cursorX = 0
+	case '\n':
+		s.lineFeed()
+	case '\b':
+		if s.cursorX > 0 {
+			s.cursorX--
+		}
+	case '\t':
+		s.cursorX = min((s.cursorX/8+1)*8, s.width-1)
+	}
+}
+
+// lineFeed moves the cursor down, scrolling the scroll region at its bottom row
+func (s *Screen) lineFeed() {
+	if s.cursorY == s.bottom {
+		s.scrollUp(1)
+		return
+	}
+	if s.cursorY < s.height-1 {
+		s.cursorY++
+	}
+}
+
+// scrollUp moves the rows of the scroll region up by n, blanking the bottom rows
+func (s *Screen) scrollUp(n int) {
+	n = min(n, s.bottom-s.top+1)
+	copy(s.cells[s.top:s.bottom+1], s.cells[s.top+n:s.bottom+1])
+	for y := s.bottom - n + 1; y <= s.bottom; y++ {
+		s.cells[y] = blankRow(s.width)
+	}
+}
+
+// scrollDown moves the rows of the scroll region down by n, blanking the top rows
+func (s *Screen) scrollDown(n int) {
+	n = min(n, s.bottom-s.top+1)
+	copy(s.cells[s.top+n:s.bottom+1], s.cells[s.top:s.bottom+1-n])
+	for y := s.top; y < s.top+n; y++ {
+		s.cells[y] = blankRow(s.width)
+	}
+}
+
+func (s *Screen) csi(cmd ansi.Cmd, params ansi.Params) {
+	if cmd.Prefix() == '?' && (cmd.Final() == 'h' || cmd.Final() == 'l') {
+		for _, p := range params {
+			if p.Param(0) == 1049 {
+				s.setAltScreen(cmd.Final() == 'h')
+			}
+		}
+		// Other private modes (cursor visibility, mouse...) do not affect content
+		return
+	}
+	if cmd.Prefix() != 0 || cmd.Intermediate() != 0 {
+		return
+	}
+
+	param := func(i, def int) int {
+		v, _, _ := params.Param(i, def)
+		return v
+	}
+
+	switch cmd.Final() {
+	case 'A':
+		s.cursorY = max(s.cursorY-max(param(0, 1), 1), 0)
+	case 'B':
+		s.cursorY = min(s.cursorY+max(param(0, 1), 1), s.height-1)
+	case 'C':
+		s.cursorX = min(s.cursorX+max(param(0, 1), 1), s.width-1)
+	case 'D':
+		s.cursorX = max(s.cursorX-max(param(0, 1), 1), 0)
+	case 'G':
+		s.cursorX = clamp(param(0, 1)-1, 0, s.width-1)
+	case 'H', 'f':
+		s.cursorY = clamp(param(0, 1)-1, 0, s.height-1)
+		s.cursorX = clamp(param(1, 1)-1, 0, s.width-1)
+	case 'J':
+		s.eraseDisplay(param(0, 0))
+	case 'K':
+		s.eraseLine(param(0, 0))
+	case 'm':
+		s.sgr(params)
+	case 'S':
+		s.scrollUp(max(param(0, 1), 1))
+	case 'T':
+		s.scrollDown(max(param(0, 1), 1))
+	case 'r':
+		top, bottom := param(0, 1)-1, param(1, s.height)-1
+		if top < 0 || bottom >= s.height || top >= bottom {
+			top, bottom = 0, s.height-1
+		}
+		s.top, s.bottom = top, bottom
+		s.cursorX, s.cursorY = 0, 0
+	}
+}
+
+func (s *Screen) eraseLine(mode int) {
+	if s.cursorY >= s.height {
+		return
+	}
+	from, to := 0, s.width
+	switch mode {
+	case 0:
+		from = s.cursorX
+	case 1:
+		to = min(s.cursorX+1, s.width)
+	}
+	for x := from; x < to; x++ {
+		s.cells[s.cursorY][x] = Cell{Content: " "}
+	}
+}
+
+func (s *Screen) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		s.eraseLine(0)
+		for y := s.cursorY + 1; y < s.height; y++ {
+			s.cells[y] = blankRow(s.width)
+		}
+	case 1:
+		s.eraseLine(1)
+		for y := 0; y < s.cursorY; y++ {
+			s.cells[y] = blankRow(s.width)
+		}
+	default:
+		for y := range s.cells {
+			s.cells[y] = blankRow(s.width)
+		}
+	}
+}
+
+// sgr records the active SGR parameters; a 0 parameter resets them, a new
+// color replaces the previous color of the same kind and 22-29 turn off the
+// attributes they cancel
+func (s *Screen) sgr(params ansi.Params) {
+	if len(params) == 0 {
+		s.style = nil
+		return
+	}
+
+	for i := 0; i < len(params); i++ {
+		v := params[i].Param(0)
+
+		// Colon separated sub-parameters belong to the same attribute
+		attr := strconv.Itoa(v)
+		for params[i].HasMore() && i+1 < len(params) {
+			i++
+			attr += ":" + strconv.Itoa(params[i].Param(0))
+		}
+		if attr != strconv.Itoa(v) {
+			s.dropColor(colorAttr(v))
+			if v == 4 {
+				// 4:n selects the underline style, 4:0 removes it
+				s.dropStyle(func(n int) bool { return n == 4 || n == 21 })
+				if attr == "4:0" {
+					continue
+				}
+			}
+			s.style = append(s.style, attr)
+			continue
+		}
+
+		switch {
+		case v == 0:
+			s.style = nil
+		case v == 39 || v == 49 || v == 59:
+			// Default color: drop the previous color of the same kind
+			s.dropColor(v - 1)
+		case resetAttrs[v] != nil:
+			s.dropStyle(func(n int) bool { return slices.Contains(resetAttrs[v], n) })
+		case (v == 38 || v == 48 || v == 58) && i+1 < len(params):
+			// Extended colors: 38;5;n or 38;2;r;g;b
+			n := 2
+			if params[i+1].Param(0) == 2 {
+				n = 4
+			}
+			s.dropColor(v)
+			end := min(i+1+n, len(params))
+			for _, p := range params[i+1 : end] {
+				attr += ";" + strconv.Itoa(p.Param(0))
+			}
+			s.style = append(s.style, attr)
+			i = end - 1
+		default:
+			s.dropColor(colorAttr(v))
+			if v == 4 || v == 21 {
+				s.dropStyle(func(n int) bool { return n == 4 || n == 21 })
+			}
+			s.style = append(s.style, attr)
+		}
+	}
+}
+
+// dropColor removes the style attributes setting the color selected by ext:
+// 38 for the foreground, 48 for the background and 58 for underlines
+func (s *Screen) dropColor(ext int) {
+	if ext < 0 {
+		return
+	}
+	s.dropStyle(func(n int) bool { return colorAttr(n) == ext })
+}
+
+// dropStyle removes the style attributes whose first parameter matches
+func (s *Screen) dropStyle(match func(n int) bool) {
+	style := s.style[:0]
+	for _, attr := range s.style {
+		n, _ := strconv.Atoi(strings.FieldsFunc(attr, func(r rune) bool { return r == ';' || r == ':' })[0])
+		if !match(n) {
+			style = append(style, attr)
+		}
+	}
+	s.style = style
+}
+
+// resetAttrs maps the SGR parameters that turn attributes off to the
+// parameters they cancel
+var resetAttrs = map[int][]int{
+	22: {1, 2},
+	23: {3},
+	24: {4, 21},
+	25: {5, 6},
+	27: {7},
+	28: {8},
+	29: {9},
+}
+
+// colorAttr maps an SGR color parameter to the extended color parameter of
+// the same kind (38, 48 or 58), or -1 when v does not set a color
+func colorAttr(v int) int {
+	switch {
+	case v >= 30 && v <= 38, v >= 90 && v <= 97:
+		return 38
+	case v >= 40 && v <= 48, v >= 100 && v <= 107:
+		return 48
+	case v == 58:
+		return 58
+	}
+	return -1
+}
+
+func blankRow(width int) []Cell {
+	row := make([]Cell, width)
+	for x := range row {
+		row[x] = Cell{Content: " "}
+	}
+	return row
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
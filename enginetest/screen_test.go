@@ -0,0 +1,121 @@
+package enginetest
+
+import "testing"
+
+func TestScreenText(t *testing.T) {
+	s := NewScreen(10, 3)
+	_, _ = s.Write([]byte("hello\r\nworld\x1b[1;3HX"))
+
+	if got := s.String(); got != "heXlo\nworld" {
+		t.Errorf("String() = %q", got)
+	}
+	if x, y := s.Cursor(); x != 3 || y != 0 {
+		t.Errorf("Cursor() = %d, %d, want 3, 0", x, y)
+	}
+}
+
+func TestScreenErase(t *testing.T) {
+	s := NewScreen(10, 3)
+	_, _ = s.Write([]byte("aaaa\r\nbbbb\r\ncccc\x1b[2;3H\x1b[K\x1b[3;1H\x1b[2K"))
+
+	if got := s.String(); got != "aaaa\nbb" {
+		t.Errorf("String() = %q", got)
+	}
+
+	_, _ = s.Write([]byte("\x1b[2J"))
+	if got := s.String(); got != "" {
+		t.Errorf("String() after erase = %q", got)
+	}
+}
+
+func TestScreenWideCharacters(t *testing.T) {
+	s := NewScreen(5, 2)
+	_, _ = s.Write([]byte("a世b界"))
+
+	if got := s.Line(0); got != "a世b" {
+		t.Errorf("Line(0) = %q", got)
+	}
+	if got := s.Line(1); got != "界" {
+		t.Errorf("Line(1) = %q, want the glyph wrapped", got)
+	}
+	if c := s.Cell(2, 0); c.Content != "" {
+		t.Errorf("continuation cell = %+v", c)
+	}
+}
+
+func TestScreenSGR(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"\x1b[1;31mx", "1;31"},
+		{"\x1b[31m\x1b[32mx", "32"},
+		{"\x1b[38;2;1;2;3mx", "38;2;1;2;3"},
+		{"\x1b[1;2m\x1b[22mx", ""},
+		{"\x1b[4:3m\x1b[4mx", "4"},
+		{"\x1b[31;41m\x1b[39mx", "41"},
+		{"\x1b[1m\x1b[0mx", ""},
+		{"\x1b[1m\x1b[mx", ""},
+	}
+
+	for _, tt := range tests {
+		s := NewScreen(5, 1)
+		_, _ = s.Write([]byte(tt.in))
+		if got := s.Cell(0, 0).Style; got != tt.want {
+			t.Errorf("%q: style = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScreenScrollRegion(t *testing.T) {
+	s := NewScreen(5, 4)
+	_, _ = s.Write([]byte("1\r\n2\r\n3\r\n4"))
+
+	// Scroll rows 2-3 up by one
+	_, _ = s.Write([]byte("\x1b[2;3r\x1b[1S\x1b[r"))
+	if got := s.String(); got != "1\n3\n\n4" {
+		t.Errorf("after scroll up: %q", got)
+	}
+
+	_, _ = s.Write([]byte("\x1b[2;3r\x1b[1T\x1b[r"))
+	if got := s.String(); got != "1\n\n3\n4" {
+		t.Errorf("after scroll down: %q", got)
+	}
+}
+
+func TestScreenSnapshot(t *testing.T) {
+	s := NewScreen(5, 1)
+	_, _ = s.Write([]byte("a"))
+
+	snap := s.Snapshot()
+	_, _ = s.Write([]byte("b"))
+
+	if got := snap.String(); got != "a" {
+		t.Errorf("snapshot changed to %q", got)
+	}
+	if got := s.String(); got != "ab" {
+		t.Errorf("screen = %q", got)
+	}
+}
+
+func TestScreenAltScreen(t *testing.T) {
+	s := NewScreen(10, 3)
+	_, _ = s.Write([]byte("main\x1b[?1049h"))
+
+	if !s.AltScreen() || s.String() != "" {
+		t.Fatalf("alt screen: active=%v %q, want a blank alternate screen", s.AltScreen(), s.String())
+	}
+	_, _ = s.Write([]byte("\x1b[2;1Halt"))
+	if got := s.String(); got != "\nalt" {
+		t.Errorf("alt screen = %q", got)
+	}
+
+	s.Resize(12, 3)
+	_, _ = s.Write([]byte("\x1b[?1049l!"))
+	if s.AltScreen() {
+		t.Error("alt screen still active")
+	}
+	if got := s.String(); got != "main!" {
+		t.Errorf("main screen = %q, want it restored with its cursor", got)
+	}
+}
@@ -25,7 +25,7 @@ func (m animationModel) Init() engine.Msg {
 func (m animationModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
 	switch msg := msg.(type) {
 	case engine.KeyMsg:
-		if msg.Rune == 'q' {
+		if msg.String() == "q" {
 			return m, func() engine.Msg { return engine.Quit() }
 		}
 	case engine.TickMsg:
@@ -22,25 +22,25 @@ func (m gameModel) Init() engine.Msg {
 func (m gameModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
 	switch msg := msg.(type) {
 	case engine.KeyMsg:
-		switch msg.Rune {
-		case 'q':
+		switch msg.String() {
+		case "q":
 			return m, func() engine.Msg { return engine.Quit() }
-		case '↑', 'w':
+		case "up", "w":
 			if m.playerY > 0 {
 				m.playerY--
 				m.score++
 			}
-		case '↓', 's':
+		case "down", "s":
 			if m.playerY < m.height-1 {
 				m.playerY++
 				m.score++
 			}
-		case '←', 'a':
+		case "left", "a":
 			if m.playerX > 0 {
 				m.playerX--
 				m.score++
 			}
-		case '→', 'd':
+		case "right", "d":
 			if m.playerX < m.width-1 {
 				m.playerX++
 				m.score++
@@ -18,7 +18,7 @@ func (m model) Init() engine.Msg {
 func (m model) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
 	switch msg := msg.(type) {
 	case engine.KeyMsg:
-		if msg.Rune == 'q' {
+		if msg.String() == "q" {
 			return m, func() engine.Msg { return engine.Quit() }
 		}
 	}
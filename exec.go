@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+)
+
+// execMsg asks the main loop to run an external process
+type execMsg struct {
+	cmd *exec.Cmd
+	fn  func(error) Msg
+}
+
+// ExecProcess runs c with the terminal handed over to it, e.g. to open $EDITOR
+// or less. The input reader, raw mode and alternate screen are suspended while
+// the process runs and restored afterwards. fn, if not nil, turns the error
+// returned by the process into a message for the model.
+//
+// Stdout and Stderr default to the program output and os.Stderr. Stdin defaults
+// to the program input when it is a file such as os.Stdin; other readers would
+// keep the process copying input after it exits, so set Stdin explicitly.
+func ExecProcess(c *exec.Cmd, fn func(error) Msg) Cmd {
+	return func() Msg {
+		return execMsg{cmd: c, fn: fn}
+	}
+}
+
+// exec releases the terminal, runs the process and takes the terminal back
+func (p *Program) exec(m execMsg) error {
+	if f, ok := p.input.(*os.File); ok && m.cmd.Stdin == nil {
+		m.cmd.Stdin = f
+	}
+	if m.cmd.Stdout == nil {
+		m.cmd.Stdout = p.output
+	}
+	if m.cmd.Stderr == nil {
+		m.cmd.Stderr = os.Stderr
+	}
+
+	p.ReleaseTerminal()
+	runErr := m.cmd.Run()
+
+	if err := p.RestoreTerminal(); err != nil {
+		return err
+	}
+
+	if m.fn != nil {
+		go p.Send(m.fn(runErr))
+	}
+	return nil
+}
+
+// ReleaseTerminal hands the terminal back: it stops the input reader, leaves
+// the alternate screen, disables mouse and paste modes, stops the renderer and
+// restores the original tty state. Use RestoreTerminal to take it again.
+func (p *Program) ReleaseTerminal() {
+	p.ttyMtx.Lock()
+	defer p.ttyMtx.Unlock()
+
+	p.stopInput()
+	p.releaseTerminal()
+}
+
+// RestoreTerminal takes the terminal back after ReleaseTerminal: it re-enters
+// raw mode and the alternate screen, restarts the renderer and input reader
+// and repaints the view.
+func (p *Program) RestoreTerminal() error {
+	p.ttyMtx.Lock()
+	defer p.ttyMtx.Unlock()
+
+	if !p.ttyReleased.Load() {
+		return nil
+	}
+
+	if err := p.initTerminal(); err != nil {
+		return err
+	}
+	if err := p.startInput(); err != nil {
+		return err
+	}
+	p.renderer.Repaint()
+	return nil
+}
@@ -0,0 +1,67 @@
+package engine_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// frames redraws a different view for every message it receives
+type frames struct {
+	n int
+}
+
+func (m frames) Init() engine.Msg { return nil }
+
+func (m frames) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	if _, ok := msg.(engine.KeyMsg); ok {
+		return m, engine.Quit
+	}
+	m.n++
+	return m, nil
+}
+
+func (m frames) View() string { return fmt.Sprintf("frame %d", m.n) }
+
+// pixelFrames is frames drawn with the pixel renderer
+type pixelFrames struct {
+	frames
+}
+
+func (m pixelFrames) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	f, cmd := m.frames.Update(msg)
+	return pixelFrames{f.(frames)}, cmd
+}
+
+func (m pixelFrames) PixelView() *engine.PixelBuffer {
+	pb := engine.NewPixelBuffer(20, 2)
+	pb.SetString(0, 0, m.View(), engine.Pixel{FG: engine.ColorGreen})
+	return pb
+}
+
+func TestReleaseAndRestoreWhileRendering(t *testing.T) {
+	for _, m := range []engine.Model{frames{}, pixelFrames{}} {
+		t.Run(fmt.Sprintf("%T", m), func(t *testing.T) {
+			tm := enginetest.NewTestModel(t, m, enginetest.WithInitialSize(20, 4),
+				enginetest.WithProgramOptions(engine.WithFPS(120), engine.WithAltScreen()))
+			tm.WaitFor(func(s *enginetest.Screen) bool { return s.Contains("frame") })
+			p := tm.Program()
+
+			for i := 0; i < 20; i++ {
+				p.ReleaseTerminal()
+				tm.Tick()
+				if err := p.RestoreTerminal(); err != nil {
+					t.Fatalf("RestoreTerminal: %v", err)
+				}
+				// Let the render loop flush while nothing else touches the renderer
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			tm.Type("q")
+			tm.WaitFinished()
+		})
+	}
+}
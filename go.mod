@@ -6,12 +6,10 @@ toolchain go1.24.7
 
 require (
 	github.com/charmbracelet/x/ansi v0.10.1
+	github.com/lucasb-eyer/go-colorful v1.2.0
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/sys v0.36.0
 	golang.org/x/term v0.35.0
 )
 
-require (
-	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
-	github.com/mattn/go-runewidth v0.0.16 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.36.0 // indirect
-)
+require github.com/mattn/go-runewidth v0.0.16 // indirect
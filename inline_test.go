@@ -0,0 +1,77 @@
+package engine_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// logModel prints a line above its view on "p", toggles a taller view on "t"
+// and quits on "q"
+type logModel struct {
+	printed int
+	tall    bool
+}
+
+func (m logModel) Init() engine.Msg { return nil }
+
+func (m logModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	key, ok := msg.(engine.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "p":
+		m.printed++
+		return m, engine.Printf("line %d", m.printed)
+	case "t":
+		m.tall = !m.tall
+	case "q":
+		return m, engine.Quit
+	}
+	return m, nil
+}
+
+func (m logModel) View() string {
+	view := fmt.Sprintf("printed %d", m.printed)
+	if m.tall {
+		view += "\nmore\nmore"
+	}
+	return view
+}
+
+func TestInlinePrintln(t *testing.T) {
+	tm := enginetest.NewTestModel(t, logModel{}, enginetest.WithInitialSize(20, 4))
+
+	steps := []struct {
+		keys string
+		want []string
+	}{
+		{"", []string{"printed 0", "", "", ""}},
+		{"t", []string{"printed 0", "more", "more", ""}},
+		{"t", []string{"printed 0", "", "", ""}},
+		{"p", []string{"line 1", "printed 1", "", ""}},
+		{"p", []string{"line 1", "line 2", "printed 2", ""}},
+		{"p", []string{"line 1", "line 2", "line 3", "printed 3"}},
+		// Printed lines scroll into the scrollback above the screen
+		{"p", []string{"line 2", "line 3", "line 4", "printed 4"}},
+	}
+	for _, step := range steps {
+		tm.Type(step.keys)
+		want := strings.Join(step.want, "\n")
+		tm.WaitFor(func(s *enginetest.Screen) bool { return strings.Join(s.Lines(), "\n") == want })
+	}
+
+	// The last frame stays on screen with the cursor on the line below it
+	tm.Type("q")
+	final := tm.FinalScreen()
+	if got, want := strings.Join(final.Lines(), "\n"), "line 3\nline 4\nprinted 4\n"; got != want {
+		t.Errorf("final screen = %q, want %q", got, want)
+	}
+	if x, y := final.Cursor(); x != 0 || y != 3 {
+		t.Errorf("final cursor = %d,%d, want 0,3", x, y)
+	}
+}
@@ -2,52 +2,444 @@
 package engine
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
 )
 
-// ReadInput reads from stdin and sends KeyMsg/QuitMsg to the provided channel
-// Handles escape sequences for arrow keys and Ctrl+C termination
+// modeReportMsg is the terminal's reply to a DECRQM mode query. value is 0
+// when the mode is not recognized, 1 or 2 when it is set or reset, and 3 or 4
+// when it is permanently set or reset.
+type modeReportMsg struct {
+	mode  int
+	value int
+}
+
+// InputErrorMsg is sent when reading input fails. Err is io.EOF when the input
+// stream is closed. The input reader stops after sending it.
+type InputErrorMsg struct {
+	Err error
+}
+
+// ReadInput reads from stdin and sends KeyMsg/MouseMsg/PasteMsg/QuitMsg to the provided channel
+// Decodes xterm/VT220 escape sequences (cursor keys, editing keys, function keys,
+// modifiers, SS3 and Alt+key), SGR and X10 mouse reports, UTF-8 text and handles
+// Ctrl+C termination.
+// A read containing several keys produces one KeyMsg per key. It returns after
+// Ctrl+C or when stdin fails, which is reported as an InputErrorMsg.
 func ReadInput(msgs chan<- Msg) {
+	r, err := newInputReader(os.Stdin, msgs)
+	if err != nil {
+		msgs <- InputErrorMsg{Err: err}
+		return
+	}
+	r.readLoop()
+	_ = r.cr.Close()
+}
+
+// escapeTimeout is how long an escape sequence cut off at the end of a read
+// waits for the rest of its bytes before it is decoded as Alt+key
+const escapeTimeout = 100 * time.Millisecond
+
+// inputReader decodes a cancelable input stream into messages
+type inputReader struct {
+	cr   cancelReader
+	msgs chan<- Msg
+	stop chan struct{}
+	done chan struct{}
+
+	// mtx guards the bytes left undecoded by the last read and is held while
+	// messages are sent, so a timed out escape sequence keeps its order
+	mtx     sync.Mutex
+	pending []byte
+	// escapeGen changes with every read, cancelling the escape timeout
+	escapeGen int
+}
+
+// newInputReader wraps in into a cancelable reader delivering messages to msgs
+func newInputReader(in io.Reader, msgs chan<- Msg) (*inputReader, error) {
+	cr, err := newCancelReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input reader: %w", err)
+	}
+
+	return &inputReader{
+		cr:   cr,
+		msgs: msgs,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// Stop cancels the read loop and waits for it to exit when the reader supports
+// interrupting a blocked read, so the input can be handed to another reader
+func (r *inputReader) Stop() {
+	close(r.stop)
+	if r.cr.Cancel() {
+		<-r.done
+	}
+	_ = r.cr.Close()
+}
+
+// readLoop reads and decodes input until Stop is called, Ctrl+C is pressed or
+// the stream fails
+func (r *inputReader) readLoop() {
+	defer close(r.done)
+
 	buf := make([]byte, 1024)
 
 	for {
-		n, err := os.Stdin.Read(buf)
-		if err != nil || n == 0 {
+		n, err := r.cr.Read(buf)
+
+		if errors.Is(err, errCanceled) {
+			return
+		}
+		if err != nil {
+			r.mtx.Lock()
+			r.escapeGen++
+			ok := r.flushEscape()
+			r.mtx.Unlock()
+			if ok {
+				r.send(InputErrorMsg{Err: err})
+			}
+			return
+		}
+		if n == 0 {
 			continue
 		}
 
-		data := buf[:n]
-
-		if len(data) >= 3 && data[0] == 0x1b && data[1] == '[' {
-			switch data[2] {
-			case 'A':
-				msgs <- KeyMsg{Rune: '↑'}
-				continue
-			case 'B':
-				msgs <- KeyMsg{Rune: '↓'}
-				continue
-			case 'C':
-				msgs <- KeyMsg{Rune: '→'}
-				continue
-			case 'D':
-				msgs <- KeyMsg{Rune: '←'}
-				continue
+		r.mtx.Lock()
+		ok := r.decode(buf[:n])
+		r.mtx.Unlock()
+		if !ok {
+			return
+		}
+	}
+}
+
+// decode decodes data after the pending bytes and sends the messages. An
+// escape sequence left incomplete is decoded as Alt+key if nothing follows it
+// within escapeTimeout. It reports false once the loop should stop. The caller
+// holds r.mtx.
+func (r *inputReader) decode(data []byte) bool {
+	r.escapeGen++
+
+	data = append(r.pending, data...)
+	events, consumed := parseInput(data)
+	r.pending = append([]byte(nil), data[consumed:]...)
+	if msg, rest := splitPaste(r.pending); msg != nil {
+		events = append(events, msg)
+		r.pending = rest
+	}
+
+	for _, msg := range events {
+		if !r.send(msg) {
+			return false
+		}
+		if _, ok := msg.(QuitMsg); ok {
+			return false
+		}
+	}
+
+	if incompleteEscape(r.pending) {
+		gen := r.escapeGen
+		time.AfterFunc(escapeTimeout, func() {
+			r.mtx.Lock()
+			defer r.mtx.Unlock()
+			if gen == r.escapeGen {
+				r.flushEscape()
 			}
+		})
+	}
+	return true
+}
+
+// flushEscape sends the incomplete escape sequence left pending once no more
+// input arrives for it. It reports false if the reader was stopped. The caller
+// holds r.mtx.
+func (r *inputReader) flushEscape() bool {
+	if !incompleteEscape(r.pending) {
+		return true
+	}
+	events := decodeCutEscape(r.pending)
+	r.pending = nil
+
+	for _, msg := range events {
+		select {
+		case <-r.stop:
+			return false
+		default:
+		}
+		if !r.send(msg) {
+			return false
 		}
+	}
+	return true
+}
 
-		switch data[0] {
-		case 3:
-			msgs <- QuitMsg{}
-			return
-		case 0x1b:
-			if len(data) < 3 {
-				continue
+// send delivers msg unless the reader is stopped first
+func (r *inputReader) send(msg Msg) bool {
+	select {
+	case r.msgs <- msg:
+		return true
+	case <-r.stop:
+		return false
+	}
+}
+
+// parseInput splits raw terminal input into messages. It returns the decoded
+// messages and the number of bytes consumed; an incomplete UTF-8 sequence,
+// escape sequence or bracketed paste at the end of data is left unconsumed so
+// it can be completed by the next read.
+func parseInput(data []byte) ([]Msg, int) {
+	var events []Msg
+	i := 0
+
+	for i < len(data) {
+		b := data[i]
+
+		switch {
+		case b == 3:
+			return append(events, QuitMsg{}), len(data)
+		case b == 0x1b && bytes.HasPrefix(data[i:], []byte(ansi.BracketedPasteStart)):
+			msg, n, ok := decodePaste(data[i:])
+			if !ok {
+				return events, i
+			}
+			events = append(events, msg)
+			i += n
+		case b == 0x1b:
+			msg, n := decodeEscape(data[i:])
+			if n == 0 {
+				return events, i
 			}
-			fallthrough
+			if msg != nil {
+				events = append(events, msg)
+			}
+			i += n
+		case b < utf8.RuneSelf:
+			events = append(events, controlKey(b))
+			i++
 		default:
-			if len(data) == 1 {
-				msgs <- KeyMsg{Rune: rune(data[0])}
+			if !utf8.FullRune(data[i:]) {
+				return events, i
+			}
+			r, n := utf8.DecodeRune(data[i:])
+			if r != utf8.RuneError {
+				events = append(events, KeyMsg{Type: KeyRunes, Rune: r})
 			}
+			i += n
 		}
 	}
+
+	return events, i
+}
+
+// incompleteEscape reports whether pending starts with an escape sequence cut
+// off by the end of the input, other than an unterminated bracketed paste
+func incompleteEscape(pending []byte) bool {
+	return len(pending) > 1 && pending[0] == 0x1b && !bytes.HasPrefix(pending, []byte(ansi.BracketedPasteStart))
+}
+
+// decodeCutEscape decodes an escape sequence whose remaining bytes never
+// arrived: ESC and the byte after it are Alt+key, the rest are plain keys
+func decodeCutEscape(b []byte) []Msg {
+	if b[1] >= utf8.RuneSelf {
+		// A partial UTF-8 sequence after ESC
+		return nil
+	}
+
+	k := controlKey(b[1])
+	k.Alt = true
+	events, _ := parseInput(b[2:])
+	return append([]Msg{k}, events...)
+}
+
+// decodePaste collects the text between the bracketed paste start and end
+// markers at the start of b. It reports false if the end marker has not arrived yet.
+func decodePaste(b []byte) (Msg, int, bool) {
+	start := len(ansi.BracketedPasteStart)
+	end := bytes.Index(b[start:], []byte(ansi.BracketedPasteEnd))
+	if end < 0 {
+		return nil, 0, false
+	}
+
+	return pasteMsg(b[start : start+end]), start + end + len(ansi.BracketedPasteEnd), true
+}
+
+// maxPasteSize caps the text buffered for a bracketed paste whose end marker
+// has not arrived. Longer pastes are delivered in several PasteMsg chunks.
+const maxPasteSize = 1 << 20
+
+// splitPaste flushes the text of an unterminated paste at the start of pending
+// once it grows past maxPasteSize. The rest stays pending behind a new start
+// marker so the paste continues in the next chunk; msg is nil otherwise.
+func splitPaste(pending []byte) (Msg, []byte) {
+	start := len(ansi.BracketedPasteStart)
+	if len(pending)-start <= maxPasteSize || !bytes.HasPrefix(pending, []byte(ansi.BracketedPasteStart)) {
+		return nil, pending
+	}
+
+	// Keep a partial end marker, UTF-8 sequence or CRLF for the next chunk
+	cut := len(pending) - len(ansi.BracketedPasteEnd)
+	for cut > start && !utf8.RuneStart(pending[cut]) {
+		cut--
+	}
+	if pending[cut-1] == '\r' {
+		cut--
+	}
+
+	rest := append([]byte(ansi.BracketedPasteStart), pending[cut:]...)
+	return pasteMsg(pending[start:cut]), rest
+}
+
+// pasteMsg builds a PasteMsg with the line endings of text normalized to \n
+func pasteMsg(text []byte) PasteMsg {
+	s := strings.ReplaceAll(string(text), "\r\n", "\n")
+	return PasteMsg{Text: strings.ReplaceAll(s, "\r", "\n")}
+}
+
+// decodeEscape decodes the escape sequence at the start of b and returns the
+// resulting message (nil for unrecognised sequences) and the number of bytes
+// consumed, which is 0 when the sequence is cut off by the end of b
+func decodeEscape(b []byte) (Msg, int) {
+	if len(b) == 1 {
+		return controlKey(0x1b), 1
+	}
+
+	switch b[1] {
+	case '[':
+		return decodeCSI(b)
+	case 'O':
+		if len(b) == 2 {
+			return nil, 0
+		}
+		if t, ok := csiFinalKeys[b[2]]; ok {
+			return newKey(t), 3
+		}
+	}
+
+	// ESC followed by a plain key is Alt+key
+	if b[1] >= utf8.RuneSelf {
+		if !utf8.FullRune(b[1:]) {
+			return nil, 0
+		}
+		r, n := utf8.DecodeRune(b[1:])
+		if r == utf8.RuneError {
+			return nil, 1 + n
+		}
+		return KeyMsg{Type: KeyRunes, Rune: r, Alt: true}, 1 + n
+	}
+	k := controlKey(b[1])
+	k.Alt = true
+	return k, 2
+}
+
+// decodeCSI decodes a "ESC [ params intermediates final" control sequence
+func decodeCSI(b []byte) (Msg, int) {
+	// Linux console function keys: ESC [ [ A..E
+	if len(b) > 2 && b[2] == '[' {
+		if len(b) == 3 {
+			return nil, 0
+		}
+		if t, ok := linuxConsoleKeys[b[3]]; ok {
+			return newKey(t), 4
+		}
+	}
+
+	// X10 mouse: ESC [ M followed by three raw bytes
+	if len(b) > 2 && b[2] == 'M' {
+		if len(b) < 6 {
+			return nil, 0
+		}
+		return parseX10Mouse(b[3:6]), 6
+	}
+
+	i := 2
+	for i < len(b) && b[i] >= 0x30 && b[i] <= 0x3f {
+		i++
+	}
+	paramEnd := i
+	for i < len(b) && b[i] >= 0x20 && b[i] <= 0x2f {
+		i++
+	}
+	if i >= len(b) {
+		// The rest of the sequence is in the next read
+		return nil, 0
+	}
+	if b[i] < 0x40 || b[i] > 0x7e {
+		// Not a CSI sequence: treat as Alt+[
+		return KeyMsg{Type: KeyRunes, Rune: '[', Alt: true}, 2
+	}
+
+	final := b[i]
+	n := i + 1
+	paramStr := string(b[2:paramEnd])
+
+	// SGR mouse: ESC [ < b ; x ; y M|m
+	if strings.HasPrefix(paramStr, "<") {
+		if final == 'M' || final == 'm' {
+			return parseSGRMouse(parseParams(paramStr[1:]), final), n
+		}
+		return nil, n
+	}
+
+	// Mode report (DECRPM): ESC [ ? mode ; value $ y
+	if strings.HasPrefix(paramStr, "?") && final == 'y' && string(b[paramEnd:i]) == "$" {
+		params := parseParams(paramStr[1:])
+		if len(params) < 2 {
+			return nil, n
+		}
+		return modeReportMsg{mode: params[0], value: params[1]}, n
+	}
+
+	params := parseParams(paramStr)
+
+	switch {
+	case final == '~' && len(params) > 0:
+		t, ok := csiTildeKeys[params[0]]
+		if !ok {
+			return nil, n
+		}
+		k := newKey(t)
+		if len(params) > 1 {
+			k = applyModifiers(k, params[1])
+		}
+		return k, n
+	case final == 'Z':
+		return KeyMsg{Type: KeyTab, Rune: '\t', Shift: true}, n
+	}
+
+	if t, ok := csiFinalKeys[final]; ok {
+		k := newKey(t)
+		if len(params) > 1 {
+			k = applyModifiers(k, params[1])
+		}
+		return k, n
+	}
+
+	return nil, n
+}
+
+// parseParams splits a semicolon separated CSI parameter string into integers.
+// Missing or malformed values are returned as 0.
+func parseParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	parts := strings.Split(s, ";")
+	params := make([]int, len(parts))
+	for i, p := range parts {
+		params[i], _ = strconv.Atoi(p)
+	}
+	return params
 }
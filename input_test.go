@@ -0,0 +1,261 @@
+package engine
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestParseInputKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Msg
+	}{
+		{"rune", "q", []Msg{KeyMsg{Type: KeyRunes, Rune: 'q'}}},
+		{"runes", "ab", []Msg{KeyMsg{Type: KeyRunes, Rune: 'a'}, KeyMsg{Type: KeyRunes, Rune: 'b'}}},
+		{"utf8", "é世", []Msg{KeyMsg{Type: KeyRunes, Rune: 'é'}, KeyMsg{Type: KeyRunes, Rune: '世'}}},
+		{"enter", "\r", []Msg{KeyMsg{Type: KeyEnter, Rune: '\r'}}},
+		{"tab", "\t", []Msg{KeyMsg{Type: KeyTab, Rune: '\t'}}},
+		{"backspace", "\x7f", []Msg{KeyMsg{Type: KeyBackspace, Rune: 0x7f}}},
+		{"space", " ", []Msg{KeyMsg{Type: KeySpace, Rune: ' '}}},
+		{"escape", "\x1b", []Msg{KeyMsg{Type: KeyEscape, Rune: 0x1b}}},
+		{"ctrl+q", "\x11", []Msg{KeyMsg{Type: KeyRunes, Rune: 0x11, Ctrl: true}}},
+		{"ctrl+space", "\x00", []Msg{KeyMsg{Type: KeySpace, Rune: 0, Ctrl: true}}},
+		{"ctrl+c", "\x03", []Msg{QuitMsg{}}},
+
+		{"csi up", "\x1b[A", []Msg{KeyMsg{Type: KeyUp, Rune: '↑'}}},
+		{"csi down", "\x1b[B", []Msg{KeyMsg{Type: KeyDown, Rune: '↓'}}},
+		{"csi right", "\x1b[C", []Msg{KeyMsg{Type: KeyRight, Rune: '→'}}},
+		{"csi left", "\x1b[D", []Msg{KeyMsg{Type: KeyLeft, Rune: '←'}}},
+		{"csi home", "\x1b[H", []Msg{KeyMsg{Type: KeyHome}}},
+		{"csi end", "\x1b[F", []Msg{KeyMsg{Type: KeyEnd}}},
+		{"csi shift+tab", "\x1b[Z", []Msg{KeyMsg{Type: KeyTab, Rune: '\t', Shift: true}}},
+
+		{"ss3 up", "\x1bOA", []Msg{KeyMsg{Type: KeyUp, Rune: '↑'}}},
+		{"ss3 f1", "\x1bOP", []Msg{KeyMsg{Type: KeyF1}}},
+		{"ss3 f4", "\x1bOS", []Msg{KeyMsg{Type: KeyF4}}},
+
+		{"tilde home", "\x1b[1~", []Msg{KeyMsg{Type: KeyHome}}},
+		{"tilde insert", "\x1b[2~", []Msg{KeyMsg{Type: KeyInsert}}},
+		{"tilde delete", "\x1b[3~", []Msg{KeyMsg{Type: KeyDelete}}},
+		{"tilde pgup", "\x1b[5~", []Msg{KeyMsg{Type: KeyPgUp}}},
+		{"tilde pgdown", "\x1b[6~", []Msg{KeyMsg{Type: KeyPgDown}}},
+		{"tilde f5", "\x1b[15~", []Msg{KeyMsg{Type: KeyF5}}},
+		{"tilde f12", "\x1b[24~", []Msg{KeyMsg{Type: KeyF12}}},
+		{"tilde unknown", "\x1b[99~", nil},
+		{"linux f1", "\x1b[[A", []Msg{KeyMsg{Type: KeyF1}}},
+
+		{"shift+up", "\x1b[1;2A", []Msg{KeyMsg{Type: KeyUp, Rune: '↑', Shift: true}}},
+		{"alt+up", "\x1b[1;3A", []Msg{KeyMsg{Type: KeyUp, Rune: '↑', Alt: true}}},
+		{"ctrl+right", "\x1b[1;5C", []Msg{KeyMsg{Type: KeyRight, Rune: '→', Ctrl: true}}},
+		{"ctrl+alt+shift+left", "\x1b[1;8D", []Msg{KeyMsg{Type: KeyLeft, Rune: '←', Alt: true, Ctrl: true, Shift: true}}},
+		{"ctrl+delete", "\x1b[3;5~", []Msg{KeyMsg{Type: KeyDelete, Ctrl: true}}},
+		{"shift+f5", "\x1b[15;2~", []Msg{KeyMsg{Type: KeyF5, Shift: true}}},
+
+		{"alt+q", "\x1bq", []Msg{KeyMsg{Type: KeyRunes, Rune: 'q', Alt: true}}},
+		{"alt+utf8", "\x1bé", []Msg{KeyMsg{Type: KeyRunes, Rune: 'é', Alt: true}}},
+		{"alt+enter", "\x1b\r", []Msg{KeyMsg{Type: KeyEnter, Rune: '\r', Alt: true}}},
+		{"alt+ctrl+q", "\x1b\x11", []Msg{KeyMsg{Type: KeyRunes, Rune: 0x11, Alt: true, Ctrl: true}}},
+
+		{"keys around sequence", "a\x1b[Ab", []Msg{
+			KeyMsg{Type: KeyRunes, Rune: 'a'},
+			KeyMsg{Type: KeyUp, Rune: '↑'},
+			KeyMsg{Type: KeyRunes, Rune: 'b'},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := parseInput([]byte(tt.in))
+			if n != len(tt.in) {
+				t.Errorf("consumed %d bytes, want %d", n, len(tt.in))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInputPartialUTF8(t *testing.T) {
+	in := []byte("a世")
+	got, n := parseInput(in[:len(in)-1])
+	if n != 1 {
+		t.Fatalf("consumed %d bytes, want 1", n)
+	}
+	if want := []Msg{KeyMsg{Type: KeyRunes, Rune: 'a'}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestKeyMsgString(t *testing.T) {
+	tests := []struct {
+		key  KeyMsg
+		want string
+	}{
+		{KeyMsg{Type: KeyRunes, Rune: 'q'}, "q"},
+		{KeyMsg{Type: KeyRunes, Rune: 'q', Alt: true}, "alt+q"},
+		{KeyMsg{Type: KeyRunes, Rune: 0x11, Ctrl: true}, "ctrl+q"},
+		{KeyMsg{Type: KeyRunes, Rune: 0x1c, Ctrl: true}, "ctrl+\\"},
+		{KeyMsg{Type: KeyRight, Rune: '→', Ctrl: true}, "ctrl+right"},
+		{KeyMsg{Type: KeyF5, Alt: true, Shift: true}, "alt+shift+f5"},
+		{KeyMsg{Type: KeyEnter, Rune: '\r'}, "enter"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestParseInputPartialX10Mouse(t *testing.T) {
+	report := "\x1b[M !!"
+	full, _ := parseInput([]byte(report + "q"))
+
+	got, n := parseInput([]byte("a" + report[:4]))
+	if n != 1 {
+		t.Fatalf("consumed %d bytes, want 1", n)
+	}
+	if want := []Msg{KeyMsg{Type: KeyRunes, Rune: 'a'}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	// The next read completes the report and keeps the keys after it
+	got, n = parseInput([]byte(report[:4] + report[4:] + "q"))
+	if n != len(report)+1 {
+		t.Fatalf("consumed %d bytes, want %d", n, len(report)+1)
+	}
+	if len(got) != 2 || !reflect.DeepEqual(got, full) {
+		t.Errorf("got %#v, want %#v", got, full)
+	}
+	if _, ok := got[0].(MouseMsg); !ok {
+		t.Errorf("got %#v, want a MouseMsg first", got[0])
+	}
+}
+
+func TestSplitPaste(t *testing.T) {
+	text := strings.Repeat("x", maxPasteSize) + "é\r"
+	pending := []byte(ansi.BracketedPasteStart + text)
+
+	msg, rest := splitPaste(pending)
+	paste, ok := msg.(PasteMsg)
+	if !ok {
+		t.Fatalf("got %#v, want a PasteMsg", msg)
+	}
+	if paste.Text+string(rest[len(ansi.BracketedPasteStart):]) != text {
+		t.Errorf("chunk and rest do not add up to the pasted text")
+	}
+	if strings.HasSuffix(paste.Text, "\n") {
+		t.Errorf("chunk ends with a lone CR turned into a newline")
+	}
+
+	// The rest continues the paste
+	got, n := parseInput(append(rest, ansi.BracketedPasteEnd+"q"...))
+	if n != len(rest)+len(ansi.BracketedPasteEnd)+1 || len(got) != 2 {
+		t.Fatalf("got %#v after consuming %d bytes", got, n)
+	}
+	if _, ok := got[0].(PasteMsg); !ok {
+		t.Errorf("got %#v, want a PasteMsg", got[0])
+	}
+
+	if msg, _ := splitPaste([]byte(ansi.BracketedPasteStart + "short")); msg != nil {
+		t.Errorf("short paste flushed early: %#v", msg)
+	}
+}
+
+func TestParseInputPartialEscape(t *testing.T) {
+	for _, seq := range []string{
+		"\x1b[<0;10;5M",
+		"\x1b[1;5C",
+		"\x1b[15;2~",
+		"\x1bOP",
+		"\x1b[[A",
+		"\x1bé",
+		"\x1b[?2026;2$y",
+	} {
+		full, _ := parseInput([]byte(seq))
+		if len(full) != 1 {
+			t.Fatalf("%q decodes to %#v", seq, full)
+		}
+
+		for cut := 2; cut < len(seq); cut++ {
+			got, n := parseInput([]byte("a" + seq[:cut]))
+			if n != 1 || len(got) != 1 {
+				t.Errorf("%q: consumed %d bytes into %#v, want only the key before it", seq[:cut], n, got)
+				continue
+			}
+
+			// The next read completes the sequence
+			got, n = parseInput([]byte(seq[:cut] + seq[cut:]))
+			if n != len(seq) || !reflect.DeepEqual(got, full) {
+				t.Errorf("%q + %q: got %#v, want %#v", seq[:cut], seq[cut:], got, full)
+			}
+		}
+	}
+}
+
+func TestDecodeCutEscape(t *testing.T) {
+	got := decodeCutEscape([]byte("\x1b[1;"))
+	want := []Msg{
+		KeyMsg{Type: KeyRunes, Rune: '[', Alt: true},
+		KeyMsg{Type: KeyRunes, Rune: '1'},
+		KeyMsg{Type: KeyRunes, Rune: ';'},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestReadLoopSplitEscape(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	msgs := make(chan Msg, 10)
+	ir, err := newInputReader(r, msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go ir.readLoop()
+	defer ir.Stop()
+
+	next := func() Msg {
+		t.Helper()
+		select {
+		case msg := <-msgs:
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("no message")
+			return nil
+		}
+	}
+
+	// A mouse report split across two reads
+	_, _ = w.WriteString("\x1b[<0;10")
+	time.Sleep(escapeTimeout / 4)
+	_, _ = w.WriteString(";5M")
+	if got, want := next(), (MouseMsg{X: 9, Y: 4, Button: MouseButtonLeft, Action: MouseActionPress}); got != want {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	// Alt+[ with nothing after it
+	_, _ = w.WriteString("\x1b[")
+	if got, want := next(), (KeyMsg{Type: KeyRunes, Rune: '[', Alt: true}); got != want {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	select {
+	case msg := <-msgs:
+		t.Errorf("unexpected %#v", msg)
+	case <-time.After(2 * escapeTimeout):
+	}
+}
@@ -0,0 +1,218 @@
+package engine
+
+import "strings"
+
+// KeyType identifies the kind of key carried by a KeyMsg
+type KeyType int
+
+const (
+	// KeyRunes is a printable character, stored in KeyMsg.Rune
+	KeyRunes KeyType = iota
+	KeyEnter
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeySpace
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDown
+	KeyInsert
+	KeyDelete
+	KeyF1
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+)
+
+var keyNames = map[KeyType]string{
+	KeyEnter:     "enter",
+	KeyTab:       "tab",
+	KeyBackspace: "backspace",
+	KeyEscape:    "esc",
+	KeySpace:     "space",
+	KeyUp:        "up",
+	KeyDown:      "down",
+	KeyRight:     "right",
+	KeyLeft:      "left",
+	KeyHome:      "home",
+	KeyEnd:       "end",
+	KeyPgUp:      "pgup",
+	KeyPgDown:    "pgdown",
+	KeyInsert:    "insert",
+	KeyDelete:    "delete",
+	KeyF1:        "f1",
+	KeyF2:        "f2",
+	KeyF3:        "f3",
+	KeyF4:        "f4",
+	KeyF5:        "f5",
+	KeyF6:        "f6",
+	KeyF7:        "f7",
+	KeyF8:        "f8",
+	KeyF9:        "f9",
+	KeyF10:       "f10",
+	KeyF11:       "f11",
+	KeyF12:       "f12",
+}
+
+// String returns the key type name, e.g. "pgup" or "f5"
+func (k KeyType) String() string {
+	if name, ok := keyNames[k]; ok {
+		return name
+	}
+	return "runes"
+}
+
+// KeyMsg is sent for every key press read from the terminal.
+// Rune keeps the legacy values ('↑', '↓', '→', '←' for arrows, the raw
+// control byte for enter/tab/backspace/esc and Ctrl+letter) so existing
+// switches keep working. Alt+key sets Rune to the key and Alt to true, so
+// match on String() to tell "q" from "alt+q".
+type KeyMsg struct {
+	Type  KeyType
+	Rune  rune
+	Alt   bool
+	Ctrl  bool
+	Shift bool
+}
+
+// String returns a readable key description such as "a", "ctrl+right" or "alt+shift+f5"
+func (k KeyMsg) String() string {
+	var sb strings.Builder
+	if k.Ctrl {
+		sb.WriteString("ctrl+")
+	}
+	if k.Alt {
+		sb.WriteString("alt+")
+	}
+	if k.Shift {
+		sb.WriteString("shift+")
+	}
+	switch {
+	case k.Type == KeyRunes && k.Rune < 0x20:
+		sb.WriteRune(ctrlRune(k.Rune))
+	case k.Type == KeyRunes:
+		sb.WriteRune(k.Rune)
+	default:
+		sb.WriteString(k.Type.String())
+	}
+	return sb.String()
+}
+
+// arrowRunes maps arrow key types to the runes historically sent in KeyMsg.Rune
+var arrowRunes = map[KeyType]rune{
+	KeyUp:    '↑',
+	KeyDown:  '↓',
+	KeyRight: '→',
+	KeyLeft:  '←',
+}
+
+// newKey builds a KeyMsg for a special key, filling Rune for arrows
+func newKey(t KeyType) KeyMsg {
+	return KeyMsg{Type: t, Rune: arrowRunes[t]}
+}
+
+// csiFinalKeys maps CSI/SS3 final bytes to key types ("ESC [ A", "ESC O P", ...)
+var csiFinalKeys = map[byte]KeyType{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'P': KeyF1,
+	'Q': KeyF2,
+	'R': KeyF3,
+	'S': KeyF4,
+}
+
+// csiTildeKeys maps the numeric parameter of "ESC [ n ~" sequences (VT220 style)
+var csiTildeKeys = map[int]KeyType{
+	1:  KeyHome,
+	2:  KeyInsert,
+	3:  KeyDelete,
+	4:  KeyEnd,
+	5:  KeyPgUp,
+	6:  KeyPgDown,
+	7:  KeyHome,
+	8:  KeyEnd,
+	11: KeyF1,
+	12: KeyF2,
+	13: KeyF3,
+	14: KeyF4,
+	15: KeyF5,
+	17: KeyF6,
+	18: KeyF7,
+	19: KeyF8,
+	20: KeyF9,
+	21: KeyF10,
+	23: KeyF11,
+	24: KeyF12,
+}
+
+// linuxConsoleKeys maps "ESC [ [ X" function keys sent by the Linux console
+var linuxConsoleKeys = map[byte]KeyType{
+	'A': KeyF1,
+	'B': KeyF2,
+	'C': KeyF3,
+	'D': KeyF4,
+	'E': KeyF5,
+}
+
+// applyModifiers decodes an xterm modifier parameter (1 + bitmask) onto the key
+func applyModifiers(k KeyMsg, param int) KeyMsg {
+	if param < 2 {
+		return k
+	}
+	mask := param - 1
+	k.Shift = mask&1 != 0
+	k.Alt = mask&2 != 0
+	k.Ctrl = mask&4 != 0
+	return k
+}
+
+// controlKey decodes a single C0 control byte or DEL into a KeyMsg
+func controlKey(b byte) KeyMsg {
+	switch b {
+	case '\r', '\n':
+		return KeyMsg{Type: KeyEnter, Rune: rune(b)}
+	case '\t':
+		return KeyMsg{Type: KeyTab, Rune: '\t'}
+	case 0x7f, 0x08:
+		return KeyMsg{Type: KeyBackspace, Rune: rune(b)}
+	case 0x1b:
+		return KeyMsg{Type: KeyEscape, Rune: 0x1b}
+	case ' ':
+		return KeyMsg{Type: KeySpace, Rune: ' '}
+	case 0:
+		return KeyMsg{Type: KeySpace, Rune: 0, Ctrl: true}
+	}
+	if b < 0x20 {
+		// Ctrl+A..Ctrl+Z and friends keep the raw control byte in Rune (0x11
+		// for Ctrl+Q), so checks such as Rune == 'q' do not match them
+		return KeyMsg{Type: KeyRunes, Rune: rune(b), Ctrl: true}
+	}
+	return KeyMsg{Type: KeyRunes, Rune: rune(b)}
+}
+
+// ctrlRune returns the key pressed with Ctrl to produce a control byte:
+// 0x01 -> 'a', 0x1c -> '\\'
+func ctrlRune(r rune) rune {
+	r += '@'
+	if r >= 'A' && r <= 'Z' {
+		r += 'a' - 'A'
+	}
+	return r
+}
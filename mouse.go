@@ -0,0 +1,168 @@
+package engine
+
+import "strings"
+
+// MouseAction describes what happened to the mouse button
+type MouseAction int
+
+const (
+	MouseActionPress MouseAction = iota
+	MouseActionRelease
+	MouseActionMotion
+)
+
+var mouseActions = map[MouseAction]string{
+	MouseActionPress:   "press",
+	MouseActionRelease: "release",
+	MouseActionMotion:  "motion",
+}
+
+// String returns the action name
+func (a MouseAction) String() string {
+	return mouseActions[a]
+}
+
+// MouseButton identifies the button involved in a mouse event
+type MouseButton int
+
+const (
+	MouseButtonNone MouseButton = iota
+	MouseButtonLeft
+	MouseButtonMiddle
+	MouseButtonRight
+	MouseButtonWheelUp
+	MouseButtonWheelDown
+	MouseButtonWheelLeft
+	MouseButtonWheelRight
+	MouseButtonBackward
+	MouseButtonForward
+	MouseButton10
+	MouseButton11
+)
+
+var mouseButtons = map[MouseButton]string{
+	MouseButtonNone:       "none",
+	MouseButtonLeft:       "left",
+	MouseButtonMiddle:     "middle",
+	MouseButtonRight:      "right",
+	MouseButtonWheelUp:    "wheel up",
+	MouseButtonWheelDown:  "wheel down",
+	MouseButtonWheelLeft:  "wheel left",
+	MouseButtonWheelRight: "wheel right",
+	MouseButtonBackward:   "backward",
+	MouseButtonForward:    "forward",
+	MouseButton10:         "button 10",
+	MouseButton11:         "button 11",
+}
+
+// String returns the button name
+func (b MouseButton) String() string {
+	return mouseButtons[b]
+}
+
+// MouseMsg is sent for mouse events when mouse reporting is enabled with
+// WithMouseCellMotion or WithMouseAllMotion. X and Y are 0-based cell coordinates.
+type MouseMsg struct {
+	X      int
+	Y      int
+	Button MouseButton
+	Action MouseAction
+	Alt    bool
+	Ctrl   bool
+	Shift  bool
+}
+
+// IsWheel reports whether the event comes from a scroll wheel
+func (m MouseMsg) IsWheel() bool {
+	return m.Button >= MouseButtonWheelUp && m.Button <= MouseButtonWheelRight
+}
+
+// String returns a readable description such as "ctrl+left press" or "wheel up"
+func (m MouseMsg) String() string {
+	var sb strings.Builder
+	if m.Ctrl {
+		sb.WriteString("ctrl+")
+	}
+	if m.Alt {
+		sb.WriteString("alt+")
+	}
+	if m.Shift {
+		sb.WriteString("shift+")
+	}
+
+	if m.IsWheel() {
+		sb.WriteString(m.Button.String())
+		return sb.String()
+	}
+
+	if m.Button != MouseButtonNone {
+		sb.WriteString(m.Button.String())
+		sb.WriteByte(' ')
+	}
+	sb.WriteString(m.Action.String())
+	return sb.String()
+}
+
+const (
+	mouseBitShift  = 0b0000_0100
+	mouseBitAlt    = 0b0000_1000
+	mouseBitCtrl   = 0b0001_0000
+	mouseBitMotion = 0b0010_0000
+	mouseBitWheel  = 0b0100_0000
+	mouseBitExtra  = 0b1000_0000
+	mouseBitsLow   = 0b0000_0011
+)
+
+// parseMouseButton decodes the xterm button byte shared by the X10 and SGR encodings
+func parseMouseButton(b int, release bool) MouseMsg {
+	m := MouseMsg{
+		Shift: b&mouseBitShift != 0,
+		Alt:   b&mouseBitAlt != 0,
+		Ctrl:  b&mouseBitCtrl != 0,
+	}
+
+	low := b & mouseBitsLow
+	switch {
+	case b&mouseBitExtra != 0:
+		m.Button = MouseButtonBackward + MouseButton(low)
+	case b&mouseBitWheel != 0:
+		m.Button = MouseButtonWheelUp + MouseButton(low)
+	case low == 3:
+		// X10 encodes every release as button 3
+		m.Button = MouseButtonNone
+		release = true
+	default:
+		m.Button = MouseButtonLeft + MouseButton(low)
+	}
+
+	switch {
+	case b&mouseBitMotion != 0 && !m.IsWheel():
+		m.Action = MouseActionMotion
+	case release:
+		m.Action = MouseActionRelease
+	default:
+		m.Action = MouseActionPress
+	}
+
+	return m
+}
+
+// parseSGRMouse decodes the parameters of "ESC [ < b ; x ; y M|m"
+func parseSGRMouse(params []int, final byte) Msg {
+	if len(params) < 3 {
+		return nil
+	}
+	m := parseMouseButton(params[0], final == 'm')
+	m.X = params[1] - 1
+	m.Y = params[2] - 1
+	return m
+}
+
+// parseX10Mouse decodes the three raw bytes following "ESC [ M"
+func parseX10Mouse(b []byte) Msg {
+	const offset = 32
+	m := parseMouseButton(int(b[0])-offset, false)
+	m.X = int(b[1]) - offset - 1
+	m.Y = int(b[2]) - offset - 1
+	return m
+}
@@ -0,0 +1,104 @@
+package engine_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// newScreenProgram returns a program running m in the alternate screen of a
+// virtual terminal
+func newScreenProgram(t *testing.T, m engine.Model, opts ...engine.ProgramOption) (*engine.Program, *enginetest.Screen) {
+	t.Helper()
+
+	in, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = in.Close()
+		_ = inW.Close()
+	})
+
+	screen := enginetest.NewScreen(20, 4)
+	opts = append([]engine.ProgramOption{
+		engine.WithInput(in), engine.WithOutput(screen), engine.WithWindowSize(20, 4),
+		engine.WithoutSignalHandler(), engine.WithAltScreen(),
+	}, opts...)
+	return engine.NewProgram(m, opts...), screen
+}
+
+// panicModel panics in Update, View or a Cmd once it receives its first SizeMsg
+type panicModel struct {
+	where string
+	ready bool
+}
+
+func (m panicModel) Init() engine.Msg { return nil }
+
+func (m panicModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	if _, ok := msg.(engine.SizeMsg); !ok {
+		return m, nil
+	}
+	m.ready = true
+	switch m.where {
+	case "update":
+		panic("boom in update")
+	case "cmd":
+		return m, func() engine.Msg { panic("boom in cmd") }
+	}
+	return m, nil
+}
+
+func (m panicModel) View() string {
+	if m.ready && m.where == "view" {
+		panic("boom in view")
+	}
+	return "ok"
+}
+
+func TestPanicsRestoreTheTerminal(t *testing.T) {
+	for _, where := range []string{"update", "view", "cmd"} {
+		t.Run(where, func(t *testing.T) {
+			p, screen := newScreenProgram(t, panicModel{where: where})
+
+			var err error
+			within(t, "Run", func() { err = p.Run() })
+
+			var panicErr *engine.ProgramPanicError
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("Run returned %v, want a ProgramPanicError", err)
+			}
+			if panicErr.Value != "boom in "+where {
+				t.Errorf("panic value = %v", panicErr.Value)
+			}
+			if !strings.Contains(string(panicErr.Stack), "panicModel") {
+				t.Errorf("stack does not show the panicking model:\n%s", panicErr.Stack)
+			}
+			if screen.AltScreen() {
+				t.Error("the alternate screen is still active")
+			}
+		})
+	}
+}
+
+func TestWithoutCatchPanics(t *testing.T) {
+	p, screen := newScreenProgram(t, panicModel{where: "update"}, engine.WithoutCatchPanics())
+
+	var recovered any
+	within(t, "Run", func() {
+		defer func() { recovered = recover() }()
+		_ = p.Run()
+	})
+
+	if recovered != "boom in update" {
+		t.Errorf("recovered %v, want the panic to reach the caller", recovered)
+	}
+	if screen.AltScreen() {
+		t.Error("the alternate screen is still active")
+	}
+}
@@ -4,25 +4,58 @@ import (
 	"fmt"
 	"math"
 	"strings"
-)
+	"unicode/utf8"
 
-type Color int
-
-const (
-	ColorBlack Color = iota
-	ColorRed
-	ColorGreen
-	ColorYellow
-	ColorBlue
-	ColorMagenta
-	ColorCyan
-	ColorWhite
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rivo/uniseg"
 )
 
 type Pixel struct {
 	Char rune
-	FG   Color
-	BG   Color
+	// Grapheme is drawn instead of Char when set, for grapheme clusters made
+	// of several runes such as emoji sequences or letters with combining marks
+	Grapheme string
+	FG       Color
+	BG       Color
+	Attrs    Attr
+	// UnderlineColor colors the underline; ColorDefault uses the FG color
+	UnderlineColor Color
+
+	// continuation marks the cells covered by the wide glyph to their left
+	continuation bool
+}
+
+// Content returns the text drawn in the cell: Grapheme, Char, a space for an
+// empty cell or "" for the continuation cell of a wide glyph
+func (p Pixel) Content() string {
+	switch {
+	case p.continuation:
+		return ""
+	case p.Grapheme != "":
+		return p.Grapheme
+	case p.Char == 0:
+		return " "
+	}
+	return string(p.Char)
+}
+
+// Width returns the number of columns the cell content occupies: 2 for wide
+// glyphs such as CJK characters and emoji, 0 for continuation cells and 1
+// otherwise. It matches ansi.StringWidth used by the string renderer.
+func (p Pixel) Width() int {
+	if p.continuation {
+		return 0
+	}
+	if p.Grapheme == "" && p.Char < 0x300 {
+		return 1
+	}
+	return max(min(ansi.StringWidth(p.Content()), 2), 1)
+}
+
+// IsContinuation reports whether the cell is covered by the wide glyph to
+// its left. Continuation cells are managed by SetPixel and never drawn.
+func (p Pixel) IsContinuation() bool {
+	return p.continuation
 }
 
 type PixelBuffer struct {
@@ -43,21 +76,101 @@ func NewPixelBuffer(width, height int) *PixelBuffer {
 	}
 }
 
+// Clone returns a deep copy of the buffer
+func (pb *PixelBuffer) Clone() *PixelBuffer {
+	clone := NewPixelBuffer(pb.Width, pb.Height)
+	for y := range pb.Data {
+		copy(clone.Data[y], pb.Data[y])
+	}
+	return clone
+}
+
+// SetPixel draws p at x, y. A wide glyph also covers the cells to its right
+// with continuation cells; one that does not fit before the right edge is
+// drawn as a space. Wide glyphs partially overwritten are replaced by spaces
+// so the rest of the row keeps its alignment.
 func (pb *PixelBuffer) SetPixel(x, y int, p Pixel) {
-	if x >= 0 && x < pb.Width && y >= 0 && y < pb.Height {
-		pb.Data[y][x] = p
+	if x < 0 || x >= pb.Width || y < 0 || y >= pb.Height {
+		return
+	}
+
+	p.continuation = false
+	if p.Grapheme != "" && utf8.RuneCountInString(p.Grapheme) == 1 {
+		p.Char, _ = utf8.DecodeRuneInString(p.Grapheme)
+		p.Grapheme = ""
+	}
+
+	width := p.Width()
+	if x+width > pb.Width {
+		p.Char, p.Grapheme, width = ' ', "", 1
+	}
+
+	row := pb.Data[y]
+	for i := x; i < x+width; i++ {
+		breakWide(row, i)
+	}
+
+	row[x] = p
+	for i := x + 1; i < x+width; i++ {
+		row[i] = Pixel{FG: p.FG, BG: p.BG, Attrs: p.Attrs, UnderlineColor: p.UnderlineColor, continuation: true}
+	}
+}
+
+// SetString draws the grapheme clusters of s from x, y with the colors and
+// attributes of style and returns the number of columns used
+func (pb *PixelBuffer) SetString(x, y int, s string, style Pixel) int {
+	start := x
+	state := -1
+	for len(s) > 0 {
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+
+		style.Char, style.Grapheme = 0, cluster
+		pb.SetPixel(x, y, style)
+		x += style.Width()
+	}
+	return x - start
+}
+
+// breakWide replaces the wide glyph covering row[x], if any, by spaces in
+// the cells other than x, which is about to be overwritten
+func breakWide(row []Pixel, x int) {
+	lead := x
+	for lead > 0 && row[lead].continuation {
+		lead--
+	}
+
+	width := row[lead].Width()
+	if width < 2 || lead+width <= x {
+		return
+	}
+
+	blank := Pixel{Char: ' ', FG: row[lead].FG, BG: row[lead].BG}
+	for i := lead; i < lead+width && i < len(row); i++ {
+		if i != x {
+			row[i] = blank
+		}
 	}
 }
 
 func (pb *PixelBuffer) FillRect(x, y, w, h int, p Pixel) {
-	for i := y; i < y+h && i < pb.Height; i++ {
-		for j := x; j < x+w && j < pb.Width; j++ {
-			pb.Data[i][j] = p
+	step := max(p.Width(), 1)
+	for i := max(y, 0); i < y+h && i < pb.Height; i++ {
+		for j := max(x, 0); j < x+w && j < pb.Width; j += step {
+			pb.SetPixel(j, i, p)
 		}
 	}
 }
 
 func (pb *PixelBuffer) DrawLine(x1, y1, x2, y2 int, p Pixel) {
+	plotLine(x1, y1, x2, y2, func(x, y int) {
+		pb.SetPixel(x, y, p)
+	})
+}
+
+// plotLine calls plot for every point of the line from x1, y1 to x2, y2
+// using Bresenham's algorithm
+func plotLine(x1, y1, x2, y2 int, plot func(x, y int)) {
 	dx := int(math.Abs(float64(x1 - x2)))
 	dy := int(math.Abs(float64(y1 - y2)))
 	sx := 1
@@ -73,7 +186,7 @@ func (pb *PixelBuffer) DrawLine(x1, y1, x2, y2 int, p Pixel) {
 	if dx >= dy {
 		d := 2*dy - dx
 		for i := 0; i <= dx; i++ {
-			pb.SetPixel(x, y, p)
+			plot(x, y)
 			if d > 0 {
 				y += sy
 				d -= 2 * dx
@@ -84,7 +197,7 @@ func (pb *PixelBuffer) DrawLine(x1, y1, x2, y2 int, p Pixel) {
 	} else {
 		d := 2*dx - dy
 		for i := 0; i <= dy; i++ {
-			pb.SetPixel(x, y, p)
+			plot(x, y)
 			if d > 0 {
 				x += sx
 				d -= 2 * dy
@@ -101,13 +214,20 @@ func (pb *PixelBuffer) RenderToTerminal() string {
 	for y := 0; y < pb.Height; y++ {
 		for x := 0; x < pb.Width; x++ {
 			pixel := pb.Data[y][x]
+			if pixel.continuation {
+				// Already covered by the wide glyph to the left
+				continue
+			}
 
-			// Set colors
-			output.WriteString(fmt.Sprintf("\x1b[38;5;%dm\x1b[48;5;%dm",
-				uint8(pixel.FG), uint8(pixel.BG)))
+			// Set colors and attributes
+			params := append([]string{pixel.FG.sgr(false), pixel.BG.sgr(true)}, attrSGR(AttrNone, pixel.Attrs)...)
+			if !pixel.UnderlineColor.IsDefault() {
+				params = append(params, pixel.UnderlineColor.underlineSGR())
+			}
+			output.WriteString(fmt.Sprintf("\x1b[%sm", strings.Join(params, ";")))
 
 			// Write character
-			output.WriteRune(pixel.Char)
+			output.WriteString(pixel.Content())
 
 			// Reset colors
 			output.WriteString("\x1b[0m")
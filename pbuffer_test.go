@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+)
+
+// rowCells returns the content of each cell of row y joined by "|", with ""
+// for continuation cells
+func rowCells(pb *PixelBuffer, y int) string {
+	cells := make([]string, pb.Width)
+	for x, p := range pb.Data[y] {
+		cells[x] = p.Content()
+	}
+	return strings.Join(cells, "|")
+}
+
+func TestSetPixelWideCharacters(t *testing.T) {
+	wide := Pixel{Char: '世', FG: ColorRed}
+	other := Pixel{Char: '界'}
+	narrow := Pixel{Char: 'x'}
+
+	tests := []struct {
+		name string
+		draw func(pb *PixelBuffer)
+		want string
+	}{
+		{"wide glyph covers the next cell", func(pb *PixelBuffer) {
+			pb.SetPixel(1, 0, wide)
+		}, " |世|| "},
+		{"overwriting the continuation cell", func(pb *PixelBuffer) {
+			pb.SetPixel(1, 0, wide)
+			pb.SetPixel(2, 0, narrow)
+		}, " | |x| "},
+		{"overwriting the leading cell", func(pb *PixelBuffer) {
+			pb.SetPixel(1, 0, wide)
+			pb.SetPixel(1, 0, narrow)
+		}, " |x| | "},
+		{"wide glyph over half of another", func(pb *PixelBuffer) {
+			pb.SetPixel(0, 0, wide)
+			pb.SetPixel(1, 0, other)
+		}, " |界|| "},
+		{"wide glyph in the last column", func(pb *PixelBuffer) {
+			pb.SetPixel(3, 0, wide)
+		}, " | | | "},
+		{"outside the buffer", func(pb *PixelBuffer) {
+			pb.SetPixel(-1, 0, wide)
+			pb.SetPixel(4, 0, wide)
+			pb.SetPixel(0, 1, wide)
+		}, " | | | "},
+	}
+
+	for _, tt := range tests {
+		pb := NewPixelBuffer(4, 1)
+		tt.draw(pb)
+		if got := rowCells(pb, 0); got != tt.want {
+			t.Errorf("%s: cells = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetPixelContinuationCell(t *testing.T) {
+	pb := NewPixelBuffer(4, 1)
+	pb.SetPixel(0, 0, Pixel{Char: '世', FG: ColorRed, BG: ColorBlue})
+
+	cont := pb.Data[0][1]
+	if !cont.IsContinuation() || cont.Width() != 0 {
+		t.Fatalf("cell 1 = %+v, want a continuation cell", cont)
+	}
+	if cont.FG != ColorRed || cont.BG != ColorBlue {
+		t.Errorf("continuation colors = %v, %v, want the colors of the glyph", cont.FG, cont.BG)
+	}
+
+	// Breaking the glyph keeps its background
+	pb.SetPixel(1, 0, Pixel{Char: 'x'})
+	if lead := pb.Data[0][0]; lead.Char != ' ' || lead.BG != ColorBlue {
+		t.Errorf("broken lead = %+v, want a space on the glyph background", lead)
+	}
+}
+
+func TestSetStringGraphemes(t *testing.T) {
+	pb := NewPixelBuffer(6, 1)
+	n := pb.SetString(0, 0, "é世👍🏽", Pixel{FG: ColorGreen})
+
+	if n != 5 {
+		t.Errorf("SetString used %d columns, want 5", n)
+	}
+	if got, want := rowCells(pb, 0), "é|世||👍🏽|| "; got != want {
+		t.Errorf("cells = %q, want %q", got, want)
+	}
+	if p := pb.Data[0][0]; p.Grapheme != "é" || p.FG != ColorGreen {
+		t.Errorf("cell 0 = %+v, want the combining cluster in green", p)
+	}
+}
+
+func TestSetStringSingleRunes(t *testing.T) {
+	pb := NewPixelBuffer(4, 1)
+	pb.SetString(0, 0, "世界", Pixel{})
+	pb.SetString(1, 0, "a", Pixel{})
+
+	if p := pb.Data[0][1]; p.Char != 'a' || p.Grapheme != "" {
+		t.Errorf("cell 1 = %+v, want a single rune stored as Char", p)
+	}
+	if got, want := rowCells(pb, 0), " |a|界|"; got != want {
+		t.Errorf("cells = %q, want %q", got, want)
+	}
+}
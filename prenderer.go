@@ -7,9 +7,17 @@ type PixelRenderer struct {
 	compositor *Compositor
 }
 
+// RenderPixels queues buffer as the next frame. In the alternate screen only the
+// cells that changed since the previous frame are written to the terminal;
+// inline frames are rendered as strings and diffed line by line.
 func (pr *PixelRenderer) RenderPixels(buffer *PixelBuffer) {
-	ansiString := buffer.RenderToTerminal()
-	pr.Write(ansiString)
+	pr.writeCells(buffer)
+}
+
+// RenderCanvas queues the canvas as the next frame, mapping its pixels onto
+// half or quadrant block characters
+func (pr *PixelRenderer) RenderCanvas(canvas *Canvas) {
+	pr.writeCells(canvas.ToPixelBuffer())
 }
 
 func NewPixelRenderer(out io.Writer) Renderer {
@@ -1,24 +1,117 @@
 package engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
 
 	"golang.org/x/term"
 )
 
+var (
+	// ErrProgramKilled is returned by Run when the program is stopped with Kill
+	// or its context is cancelled
+	ErrProgramKilled = errors.New("program was killed")
+	// ErrInterrupted is returned by Run when an InterruptMsg is received
+	ErrInterrupted = errors.New("program was interrupted")
+)
+
+// ProgramPanicError is returned by Run when Model.Update, View or a Cmd panics.
+// The terminal has already been restored when Run returns it.
+type ProgramPanicError struct {
+	// Value is the value passed to panic
+	Value any
+	// Stack is the stack trace of the panicking goroutine
+	Stack []byte
+}
+
+// Error returns the panic value
+func (e *ProgramPanicError) Error() string {
+	return fmt.Sprintf("program panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it is an error
+func (e *ProgramPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
 type Program struct {
 	Model    Model
 	renderer Renderer
 	msgs     chan Msg
+	errs     chan error
+
+	parentCtx context.Context
+	ctx       context.Context
+	cancel    context.CancelFunc
+	finished  chan struct{}
+
+	ttyMtx      sync.Mutex
+	ttyFd       int
+	ttyState    *term.State
+	ttyReleased atomic.Bool
+	inputReader *inputReader
+
+	input  io.Reader
+	output io.Writer
 
 	useAltScreen     bool
 	usePixelRenderer bool
+	mouseMode        mouseMode
+	bracketedPaste   bool
+	handleSignals    bool
+	catchPanics      bool
+
+	colorProfile    ColorProfile
+	hasColorProfile bool
+
+	fps               int
+	onDemandRendering bool
+
+	// width and height override the terminal size when set with WithWindowSize
+	width  int
+	height int
+
+	syncOutput   syncOutputMode
+	syncQueried  bool
+	syncDeadline time.Time
 
 	quit bool
 }
 type ProgramOption func(*Program)
 
+// mouseMode selects which mouse events the terminal reports
+type mouseMode int
+
+const (
+	mouseModeNone mouseMode = iota
+	mouseModeCellMotion
+	mouseModeAllMotion
+)
+
+// syncOutputMode selects whether frames use synchronized output (mode 2026)
+type syncOutputMode int
+
+const (
+	// syncOutputAuto queries the terminal for mode 2026 support
+	syncOutputAuto syncOutputMode = iota
+	syncOutputOn
+	syncOutputOff
+)
+
+// syncQueryTimeout bounds how long after startup a reply to the mode 2026
+// query is accepted
+const syncQueryTimeout = time.Second
+
 // WithAltScreen enables alternate screen buffer for full-screen applications
 func WithAltScreen() ProgramOption {
 	return func(p *Program) {
@@ -26,11 +119,31 @@ func WithAltScreen() ProgramOption {
 	}
 }
 
+// WithMouseCellMotion enables mouse reporting for clicks, wheel and drag motion
+func WithMouseCellMotion() ProgramOption {
+	return func(p *Program) {
+		p.mouseMode = mouseModeCellMotion
+	}
+}
+
+// WithMouseAllMotion enables mouse reporting for all events, including hover motion
+func WithMouseAllMotion() ProgramOption {
+	return func(p *Program) {
+		p.mouseMode = mouseModeAllMotion
+	}
+}
+
+// WithBracketedPaste enables bracketed paste so pasted text arrives as a single PasteMsg
+func WithBracketedPaste() ProgramOption {
+	return func(p *Program) {
+		p.bracketedPaste = true
+	}
+}
+
 // WithPixelRenderer enables pixel-based rendering instead of standard text rendering
 func WithPixelRenderer() ProgramOption {
 	return func(p *Program) {
 		p.usePixelRenderer = true
-		p.renderer = NewPixelRenderer(os.Stdout)
 	}
 }
 
@@ -39,29 +152,133 @@ func WithBase() ProgramOption {
 	return func(p *Program) {
 		p.useAltScreen = true
 		p.usePixelRenderer = true
-		p.renderer = NewPixelRenderer(os.Stdout)
 	}
 }
 
-// GetSize returns terminal width and height, defaulting to 80x24 for non-terminals
-func (p *Program) GetSize() (int, int) {
-	fd := int(os.Stdin.Fd())
+// WithInput sets the stream input is read from instead of os.Stdin.
+// Raw mode is only enabled when the reader is a terminal.
+func WithInput(in io.Reader) ProgramOption {
+	return func(p *Program) {
+		p.input = in
+	}
+}
+
+// WithOutput sets the stream the renderer writes to instead of os.Stdout
+func WithOutput(out io.Writer) ProgramOption {
+	return func(p *Program) {
+		p.output = out
+	}
+}
 
-	if !term.IsTerminal(fd) {
-		return 80, 24
+// WithContext ties the program to ctx: cancelling it stops Run, which restores
+// the terminal and returns an error wrapping ErrProgramKilled
+func WithContext(ctx context.Context) ProgramOption {
+	return func(p *Program) {
+		p.parentCtx = ctx
 	}
+}
 
-	width, height, err := term.GetSize(fd)
+// WithoutSignalHandler disables the SIGINT/SIGTERM/SIGHUP handler, e.g. when
+// the application handles signals itself
+func WithoutSignalHandler() ProgramOption {
+	return func(p *Program) {
+		p.handleSignals = false
+	}
+}
 
-	if err != nil {
-		return 80, 24
+// WithoutCatchPanics disables panic recovery so panics crash the process with
+// the original stack trace. A panic in a Cmd then leaves the terminal in raw
+// mode; use for debugging only.
+func WithoutCatchPanics() ProgramOption {
+	return func(p *Program) {
+		p.catchPanics = false
+	}
+}
+
+// WithColorProfile overrides the detected color profile, e.g. to get stable
+// output in tests. Colors the profile cannot display are downsampled.
+func WithColorProfile(profile ColorProfile) ProgramOption {
+	return func(p *Program) {
+		p.colorProfile = profile
+		p.hasColorProfile = true
+	}
+}
+
+// WithFPS sets how many frames per second the renderer flushes, from 1 to 120.
+// The default is 24.
+func WithFPS(fps int) ProgramOption {
+	return func(p *Program) {
+		p.fps = max(fps, 1)
+		p.onDemandRendering = false
+	}
+}
+
+// WithOnDemandRendering flushes every frame right after Update instead of on
+// a fixed tick, for applications that only redraw in response to events
+func WithOnDemandRendering() ProgramOption {
+	return func(p *Program) {
+		p.onDemandRendering = true
+	}
+}
+
+// WithWindowSize sets the size reported to the model and used by the renderer
+// instead of querying the terminal, e.g. when the output is not a terminal
+func WithWindowSize(width, height int) ProgramOption {
+	return func(p *Program) {
+		p.width = width
+		p.height = height
+	}
+}
+
+// WithSynchronizedOutput wraps every frame in synchronized output mode (2026)
+// without asking the terminal whether it supports it
+func WithSynchronizedOutput() ProgramOption {
+	return func(p *Program) {
+		p.syncOutput = syncOutputOn
+	}
+}
+
+// WithoutSynchronizedOutput never uses synchronized output mode (2026)
+func WithoutSynchronizedOutput() ProgramOption {
+	return func(p *Program) {
+		p.syncOutput = syncOutputOff
+	}
+}
+
+// GetSize returns terminal width and height, defaulting to 80x24 for non-terminals.
+// The size set with WithWindowSize wins; otherwise the output is queried
+// first, then the input.
+func (p *Program) GetSize() (int, int) {
+	if p.width > 0 && p.height > 0 {
+		return p.width, p.height
+	}
+
+	for _, stream := range []any{p.output, p.input} {
+		fd, ok := terminalFd(stream)
+		if !ok {
+			continue
+		}
+
+		width, height, err := term.GetSize(fd)
+		if err != nil || width <= 0 || height <= 0 {
+			continue
+		}
+
+		return width, height
 	}
 
-	if width <= 0 || height <= 0 {
-		return 80, 24
+	return 80, 24
+}
+
+// terminalFd returns the file descriptor of stream if it is a terminal
+func terminalFd(stream any) (int, bool) {
+	f, ok := stream.(interface{ Fd() uintptr })
+	if !ok {
+		return 0, false
 	}
 
-	return width, height
+	fd := int(f.Fd())
+	return fd, term.IsTerminal(fd)
 }
 
 // GetRenderer returns the renderer instance for external access
@@ -72,40 +289,74 @@ func (p *Program) GetRenderer() Renderer {
 // NewProgram creates a new Program with model and applies provided options
 func NewProgram(model Model, opts ...ProgramOption) *Program {
 	p := &Program{
-		Model:    model,
-		renderer: NewRenderer(os.Stdout),
-		msgs:     make(chan Msg),
+		Model:         model,
+		msgs:          make(chan Msg),
+		finished:      make(chan struct{}),
+		errs:          make(chan error, 1),
+		handleSignals: true,
+		catchPanics:   true,
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
 
-	return p
-}
+	if p.parentCtx == nil {
+		p.ctx, p.cancel = context.WithCancel(context.Background())
+	} else {
+		p.ctx, p.cancel = context.WithCancel(p.parentCtx)
+	}
 
-// Run starts the program main loop, setting up terminal and handling input/rendering
-func (p *Program) Run() error {
+	if p.input == nil {
+		p.input = os.Stdin
+	}
+	if p.output == nil {
+		p.output = os.Stdout
+	}
 
-	fd := int(os.Stdin.Fd())
-	oldState, err := term.MakeRaw(fd)
-	if err != nil {
-		return fmt.Errorf("failed to enter raw mode: %w", err)
+	if p.usePixelRenderer {
+		p.renderer = NewPixelRenderer(p.output)
+	} else {
+		p.renderer = NewRenderer(p.output)
 	}
-	defer func() { _ = term.Restore(fd, oldState) }()
 
-	p.renderer.Start()
-	defer p.renderer.Stop()
+	if p.hasColorProfile {
+		p.renderer.SetColorProfile(p.colorProfile)
+	}
 
-	SetGlobalRenderer(p.renderer)
+	switch {
+	case p.onDemandRendering:
+		p.renderer.SetFrameRate(0)
+	case p.fps > 0:
+		p.renderer.SetFrameRate(p.fps)
+	}
 
-	if p.useAltScreen {
-		p.renderer.EnterAltScreen()
-		defer p.renderer.ExitAltScreen()
+	return p
+}
+
+// Run starts the program main loop, setting up terminal and handling input/rendering.
+// The terminal is restored on every exit route: quit, interrupt, Kill, context
+// cancellation and panics, which are returned as a *ProgramPanicError.
+func (p *Program) Run() (err error) {
+	defer close(p.finished)
+	defer p.cancel()
+	defer func() {
+		if !p.catchPanics {
+			return
+		}
+		if r := recover(); r != nil {
+			err = &ProgramPanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	if err := p.startTerminal(); err != nil {
+		return err
 	}
+	defer p.ReleaseTerminal()
 
-	p.renderer.HideCursor()
-	go ReadInput(p.msgs)
+	if p.handleSignals {
+		go p.listenForSignals()
+	}
 
 	var cmd Cmd
 	if initialMsg := p.Model.Init(); initialMsg != nil {
@@ -113,64 +364,341 @@ func (p *Program) Run() error {
 	} else {
 		p.Model, cmd = p.Model.Update(nil)
 	}
-
-	if cmd != nil {
-		go func() {
-			p.msgs <- cmd()
-		}()
-	}
+	go p.execCmd(cmd)
 
 	width, height := p.GetSize()
+	p.renderer.Resize(width, height)
+
+	go p.listenForResize()
 
 	p.Model, cmd = p.Model.Update(SizeMsg{Width: width, Height: height})
-	if cmd != nil {
-		go func() {
-			p.msgs <- cmd()
-		}()
-	}
+	go p.execCmd(cmd)
 
 	// Initial render
-	if p.usePixelRenderer {
-		if pixelModel, ok := p.Model.(PixelModel); ok {
-			buffer := pixelModel.PixelView()
-			if pr, ok := p.renderer.(*PixelRenderer); ok {
-				pr.RenderPixels(buffer)
-			}
-		}
-	} else {
-		view := p.Model.View()
-		p.renderer.Write(view)
-	}
+	p.render()
 
 	for !p.quit {
-		if p.usePixelRenderer {
-			if pixelModel, ok := p.Model.(PixelModel); ok {
-				buffer := pixelModel.PixelView()
-				if pr, ok := p.renderer.(*PixelRenderer); ok {
-					pr.RenderPixels(buffer)
-				}
-			}
-		} else {
-			view := p.Model.View()
-			p.renderer.Write(view)
+		p.render()
+
+		var msg Msg
+		select {
+		case <-p.ctx.Done():
+			return p.killedError()
+		case err := <-p.errs:
+			return err
+		case msg = <-p.msgs:
 		}
 
-		msg := <-p.msgs
+		if p.handleScrollAreaMsg(msg) {
+			continue
+		}
 
-		if _, ok := msg.(QuitMsg); ok {
+		switch msg.(type) {
+		case SizeMsg:
+			size := msg.(SizeMsg)
+			p.renderer.Resize(size.Width, size.Height)
+		case QuitMsg:
 			p.quit = true
 			return nil
+		case InterruptMsg:
+			p.quit = true
+			return ErrInterrupted
+		case SuspendMsg:
+			if err := p.suspend(); err != nil {
+				return err
+			}
+			continue
+		case execMsg:
+			if err := p.exec(msg.(execMsg)); err != nil {
+				return err
+			}
+			continue
+		case modeReportMsg:
+			p.handleModeReport(msg.(modeReportMsg))
+			continue
+		case printLineMsg:
+			p.renderer.PrintLine(msg.(printLineMsg).text)
+			continue
 		}
 
 		var cmd Cmd
 		p.Model, cmd = p.Model.Update(msg)
+		go p.execCmd(cmd)
+	}
+
+	return nil
+}
+
+// render passes the model's view to the renderer: a PixelBuffer or Canvas
+// with the pixel renderer, the View string otherwise
+func (p *Program) render() {
+	if pr, ok := p.renderer.(*PixelRenderer); ok && p.usePixelRenderer {
+		switch m := p.Model.(type) {
+		case PixelModel:
+			if buffer := m.PixelView(); buffer != nil {
+				pr.RenderPixels(buffer)
+			}
+		case CanvasModel:
+			if canvas := m.CanvasView(); canvas != nil {
+				pr.RenderCanvas(canvas)
+			}
+		}
+		return
+	}
+
+	p.renderer.Write(p.Model.View())
+}
+
+// startTerminal initializes the terminal and starts the input reader, holding
+// ttyMtx like RestoreTerminal
+func (p *Program) startTerminal() error {
+	p.ttyMtx.Lock()
+	defer p.ttyMtx.Unlock()
+
+	if err := p.initTerminal(); err != nil {
+		return err
+	}
+	if err := p.startInput(); err != nil {
+		p.releaseTerminal()
+		return err
+	}
+	return nil
+}
+
+// initTerminal enters raw mode and applies the terminal modes requested by the options
+func (p *Program) initTerminal() error {
+	p.ttyReleased.Store(false)
+
+	if fd, ok := terminalFd(p.input); ok {
+		oldState, err := term.MakeRaw(fd)
+		if err != nil {
+			return fmt.Errorf("failed to enter raw mode: %w", err)
+		}
+		p.ttyFd = fd
+		p.ttyState = oldState
+	}
+
+	switch p.syncOutput {
+	case syncOutputOn:
+		p.renderer.SetSynchronizedOutput(true)
+	case syncOutputAuto:
+		p.querySynchronizedOutput()
+	}
+
+	p.renderer.Start()
+
+	SetGlobalRenderer(p.renderer)
+
+	if p.useAltScreen {
+		p.renderer.EnterAltScreen()
+	}
+
+	switch p.mouseMode {
+	case mouseModeCellMotion:
+		p.renderer.EnableMouseCellMotion()
+		p.renderer.EnableMouseSGRMode()
+	case mouseModeAllMotion:
+		p.renderer.EnableMouseAllMotion()
+		p.renderer.EnableMouseSGRMode()
+	}
+
+	if p.bracketedPaste {
+		p.renderer.EnableBracketedPaste()
+	}
+
+	p.renderer.HideCursor()
+	return nil
+}
+
+// querySynchronizedOutput asks the terminal whether it supports synchronized
+// output. The reply arrives as a modeReportMsg; terminals that do not support
+// DECRQM ignore the query and synchronized output stays off. The renderer is
+// not running yet, so the query cannot interleave with a frame.
+func (p *Program) querySynchronizedOutput() {
+	if p.syncQueried || p.ttyState == nil {
+		return
+	}
+	if _, ok := terminalFd(p.output); !ok {
+		return
+	}
+
+	p.syncQueried = true
+	p.syncDeadline = time.Now().Add(syncQueryTimeout)
+	_, _ = io.WriteString(p.output, ansi.RequestSynchronizedOutputMode)
+}
+
+// handleModeReport enables synchronized output when the terminal reports
+// mode 2026 as recognized in time
+func (p *Program) handleModeReport(msg modeReportMsg) {
+	if msg.mode != 2026 || p.syncOutput != syncOutputAuto || time.Now().After(p.syncDeadline) {
+		return
+	}
+	switch msg.value {
+	case 1, 2, 3:
+		p.renderer.SetSynchronizedOutput(true)
+	}
+}
+
+// releaseTerminal undoes initTerminal: terminal modes, renderer, alt screen
+// and finally the original tty state. It is a no-op when the terminal has
+// already been released.
+func (p *Program) releaseTerminal() {
+	if p.ttyReleased.Load() {
+		return
+	}
+	p.ttyReleased.Store(true)
+
+	if p.bracketedPaste {
+		p.renderer.DisableBracketedPaste()
+	}
+
+	switch p.mouseMode {
+	case mouseModeCellMotion:
+		p.renderer.DisableMouseSGRMode()
+		p.renderer.DisableMouseCellMotion()
+	case mouseModeAllMotion:
+		p.renderer.DisableMouseSGRMode()
+		p.renderer.DisableMouseAllMotion()
+	}
+
+	// Stop before leaving the alternate screen so the last flush does not draw
+	// the view onto the main screen
+	p.renderer.Stop()
+
+	if p.useAltScreen {
+		p.renderer.ExitAltScreen()
+	}
+
+	if p.ttyState != nil {
+		_ = term.Restore(p.ttyFd, p.ttyState)
+		p.ttyState = nil
+	}
+}
+
+// suspend hands the terminal back to the shell, stops the process until it is
+// continued, then takes the terminal again and delivers a ResumeMsg. Without a
+// terminal there is no shell to return to, so the process keeps running.
+func (p *Program) suspend() error {
+	_, isTTY := terminalFd(p.input)
+	p.ReleaseTerminal()
+	if isTTY {
+		suspendProcess()
+	}
+
+	if err := p.RestoreTerminal(); err != nil {
+		return err
+	}
+
+	go p.Send(ResumeMsg{})
+	return nil
+}
+
+// startInput starts the goroutine decoding p.input into messages
+func (p *Program) startInput() error {
+	r, err := newInputReader(p.input, p.msgs)
+	if err != nil {
+		return err
+	}
 
-		if cmd != nil {
+	p.inputReader = r
+	go r.readLoop()
+	return nil
+}
+
+// stopInput stops the input reader so the input stream can be used by a child
+// process or another Program. Readers that are not files cannot be interrupted:
+// a read already blocked on them only returns once more data arrives, and that
+// data is dropped.
+func (p *Program) stopInput() {
+	if p.inputReader != nil {
+		p.inputReader.Stop()
+		p.inputReader = nil
+	}
+}
+
+// killedError returns the error Run reports when the context is cancelled,
+// wrapping the cause when the context given to WithContext was cancelled
+func (p *Program) killedError() error {
+	if p.parentCtx != nil && p.parentCtx.Err() != nil {
+		return fmt.Errorf("%w: %w", ErrProgramKilled, context.Cause(p.parentCtx))
+	}
+	return ErrProgramKilled
+}
+
+// Send delivers msg to the running program. It is safe to call from any
+// goroutine: before Run it blocks until the main loop starts, and once the
+// program has exited it returns immediately without delivering the message.
+func (p *Program) Send(msg Msg) {
+	select {
+	case <-p.ctx.Done():
+	case p.msgs <- msg:
+	}
+}
+
+// Quit asks the program to exit as if the model had received a QuitMsg
+func (p *Program) Quit() {
+	p.Send(QuitMsg{})
+}
+
+// Kill stops the program immediately without waiting for pending messages.
+// Run restores the terminal and returns ErrProgramKilled.
+func (p *Program) Kill() {
+	p.cancel()
+}
+
+// Wait blocks until Run has returned
+func (p *Program) Wait() {
+	<-p.finished
+}
+
+// execCmd runs cmd and delivers its result to the main loop. Batches run their
+// commands concurrently and sequences run them in order; nested batches and
+// sequences are expanded the same way. It returns once every resulting message
+// has been delivered, and drops messages once the program has exited.
+func (p *Program) execCmd(cmd Cmd) {
+	if cmd == nil {
+		return
+	}
+	defer p.recoverCmdPanic()
+
+	switch msg := cmd().(type) {
+	case nil:
+	case BatchMsg:
+		var wg sync.WaitGroup
+		for _, c := range msg {
+			wg.Add(1)
 			go func() {
-				p.msgs <- cmd()
+				defer wg.Done()
+				p.execCmd(c)
 			}()
 		}
+		wg.Wait()
+	case SequenceMsg:
+		for _, c := range msg {
+			p.execCmd(c)
+		}
+	default:
+		p.Send(msg)
+	}
+}
+
+// recoverCmdPanic recovers a panic in a Cmd goroutine and hands it to the main
+// loop, which stops the program. It must be deferred directly.
+func (p *Program) recoverCmdPanic() {
+	if !p.catchPanics {
+		return
+	}
+	if r := recover(); r != nil {
+		select {
+		case p.errs <- &ProgramPanicError{Value: r, Stack: debug.Stack()}:
+		default:
+		}
 	}
+}
 
-	return nil
+// handleResize re-queries the terminal size and sends a SizeMsg; the main
+// loop resizes the renderer before the model sees it
+func (p *Program) handleResize() {
+	width, height := p.GetSize()
+	p.Send(SizeMsg{Width: width, Height: height})
 }
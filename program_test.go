@@ -0,0 +1,113 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// within fails the test if fn does not return within a second
+func within(t *testing.T, what string, fn func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not return", what)
+	}
+}
+
+func TestSendBeforeRun(t *testing.T) {
+	in, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer inW.Close()
+
+	p := engine.NewProgram(stepModel{}, engine.WithInput(in), engine.WithOutput(io.Discard),
+		engine.WithWindowSize(10, 2), engine.WithoutSignalHandler())
+
+	sent := make(chan struct{})
+	go func() {
+		p.Send(step("early"))
+		p.Quit()
+		close(sent)
+	}()
+
+	var m engine.Model
+	within(t, "Run", func() {
+		if err := p.Run(); err != nil {
+			t.Errorf("Run returned %v", err)
+		}
+		m = p.Model
+	})
+	<-sent
+
+	if steps := m.(stepModel).steps; len(steps) != 1 || steps[0] != "early" {
+		t.Errorf("steps = %v, want the message sent before Run", steps)
+	}
+}
+
+func TestSendAfterExit(t *testing.T) {
+	tm := enginetest.NewTestModel(t, stepModel{})
+	tm.Quit()
+
+	within(t, "Send after exit", func() { tm.Send(step("late")) })
+	within(t, "Quit after exit", tm.Program().Quit)
+
+	if steps := tm.FinalModel().(stepModel).steps; len(steps) != 0 {
+		t.Errorf("steps = %v, want none", steps)
+	}
+}
+
+func TestKill(t *testing.T) {
+	tm := enginetest.NewTestModel(t, stepModel{}, enginetest.WithProgramOptions(engine.WithAltScreen()))
+	tm.WaitFor(func(s *enginetest.Screen) bool { return s.AltScreen() })
+
+	tm.Program().Kill()
+	within(t, "Wait", tm.Program().Wait)
+
+	if err := tm.FinalError(); !errors.Is(err, engine.ErrProgramKilled) {
+		t.Errorf("Run returned %v, want ErrProgramKilled", err)
+	}
+	if tm.FinalScreen().AltScreen() {
+		t.Error("the alternate screen is still active after Kill")
+	}
+}
+
+func TestContextCancelCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	tm := enginetest.NewTestModel(t, stepModel{}, enginetest.WithProgramOptions(engine.WithContext(ctx)))
+	tm.WaitFor(func(s *enginetest.Screen) bool { return s.Contains("0") })
+
+	errShutdown := errors.New("server shutting down")
+	cancel(errShutdown)
+
+	err := tm.FinalError()
+	if !errors.Is(err, engine.ErrProgramKilled) || !errors.Is(err, errShutdown) {
+		t.Errorf("Run returned %v, want ErrProgramKilled wrapping the cancel cause", err)
+	}
+}
+
+func TestContextCancelledBeforeRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tm := enginetest.NewTestModel(t, stepModel{}, enginetest.WithProgramOptions(engine.WithContext(ctx)))
+	if err := tm.FinalError(); !errors.Is(err, engine.ErrProgramKilled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v, want ErrProgramKilled wrapping context.Canceled", err)
+	}
+}
@@ -39,8 +39,64 @@ type Renderer interface {
 	SetCursor(x, y int)
 	// Get current terminal dimensions
 	GetSize() (width int, height int)
+	// Update terminal dimensions and force a repaint
+	Resize(width, height int)
+	// Enable mouse reporting for clicks, wheel and drag motion
+	EnableMouseCellMotion()
+	// Disable mouse cell motion reporting
+	DisableMouseCellMotion()
+	// Enable mouse reporting for all motion, including hover
+	EnableMouseAllMotion()
+	// Disable mouse all motion reporting
+	DisableMouseAllMotion()
+	// Enable SGR extended mouse encoding
+	EnableMouseSGRMode()
+	// Disable SGR extended mouse encoding
+	DisableMouseSGRMode()
+	// Enable bracketed paste mode
+	EnableBracketedPaste()
+	// Disable bracketed paste mode
+	DisableBracketedPaste()
+	// Set the color profile output colors are converted to
+	SetColorProfile(ColorProfile)
+	// Get the color profile output colors are converted to
+	ColorProfile() ColorProfile
+	// Wrap each frame in synchronized output mode (2026)
+	SetSynchronizedOutput(bool)
+	// Whether or not frames are wrapped in synchronized output mode
+	SynchronizedOutput() bool
+	// Set the frames per second; 0 or less flushes each frame as it is written
+	SetFrameRate(fps int)
+	// Get frame pacing statistics
+	Stats() RenderStats
+	// Queue a line printed above the view in inline mode
+	PrintLine(string)
+	// Stop drawing view lines from to to (exclusive)
+	SetIgnoredLines(from, to int)
+	// Draw every line again
+	ClearIgnoredLines()
+	// Paint lines into the scroll area from top to bottom (exclusive)
+	PaintLines(lines []string, top, bottom int)
+	// Scroll the area down and draw lines at its top
+	InsertTop(lines []string, top, bottom int)
+	// Scroll the area up and draw lines at its bottom
+	InsertBottom(lines []string, top, bottom int)
 }
 
+// RenderStats counts the frames handled by a renderer
+type RenderStats struct {
+	// Rendered is the number of frames written to the output
+	Rendered int
+	// Skipped is the number of frames not written because they were unchanged
+	// or replaced by a newer frame before the next tick
+	Skipped int
+	// Bytes is the number of bytes written for frames
+	Bytes int64
+}
+
+// maxFPS caps the frame rate set with SetFrameRate
+const maxFPS = 120
+
 type StandardRenderer struct {
 	mtx *sync.Mutex
 	out io.Writer
@@ -48,22 +104,38 @@ type StandardRenderer struct {
 	buf                bytes.Buffer
 	queuedMessageLines []string
 	frameRate          time.Duration
+	onDemand           bool
 	ticker             *time.Ticker
 	done               chan struct{}
 	lastRender         string
 	lastRenderedLines  []string
 	linesRendered      int
 	altLinesRendered   int
-	once               sync.Once
 
 	cursorHidden bool
 
 	altScreenActive bool
 
+	colorProfile ColorProfile
+
+	syncOutput bool
+
+	// pending is set while a written frame has not been flushed yet
+	pending bool
+	// running is set between Start and Stop; frames written while stopped are
+	// kept until the renderer is started again
+	running bool
+	stats   RenderStats
+
 	width  int
 	height int
 
 	ignoreLines map[int]struct{}
+
+	// nextCells is a pending cell frame and lastCells the frame currently on
+	// screen; cell frames are diffed cell by cell instead of line by line
+	nextCells *PixelBuffer
+	lastCells *PixelBuffer
 }
 
 // NewRenderer creates a StandardRenderer with default 24fps frameRate
@@ -74,35 +146,55 @@ func NewRenderer(out io.Writer) Renderer {
 		done:               make(chan struct{}),
 		frameRate:          time.Second / time.Duration(24),
 		queuedMessageLines: []string{},
+		colorProfile:       DetectColorProfile(out),
 	}
+	// The ticker only runs between Start and Stop when not rendering on demand
+	r.ticker = time.NewTicker(r.frameRate)
+	r.ticker.Stop()
 	return r
 }
 
-// Start initializes the renderer timer and begins the background rendering loop
+// Start initializes the renderer timer and begins the background rendering loop.
+// In on-demand mode no timer runs and frames are flushed as they are written;
+// a frame written while the renderer was stopped is flushed right away.
 func (r *StandardRenderer) Start() {
-	if r.ticker == nil {
-		r.ticker = time.NewTicker(r.frameRate)
-	} else {
+	r.mtx.Lock()
+	if r.running {
+		r.mtx.Unlock()
+		return
+	}
+	r.running = true
+	if !r.onDemand {
 		r.ticker.Reset(r.frameRate)
 	}
-
-	r.once = sync.Once{}
+	flush := r.onDemand && r.pending
+	r.mtx.Unlock()
 
 	go r.listen()
+
+	if flush {
+		r.flush()
+	}
 }
 
-// Stop gracefully shuts down the renderer, flushes output, and shows cursor
+// Stop gracefully shuts down the renderer, flushes output, and shows cursor.
+// In inline mode the last frame stays on screen and the cursor moves below it;
+// the next frame after a restart is drawn from there.
 func (r *StandardRenderer) Stop() {
-	r.once.Do(func() {
-		r.done <- struct{}{}
-	})
+	r.stopListening()
 	r.flush()
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
+	if !r.altScreenActive && r.linesRendered > 0 {
+		r.execute("\r\n")
+		r.linesRendered = 0
+		r.lastRender = ""
+		r.lastRenderedLines = nil
+	}
+
 	r.execute(ansi.ShowCursor)
-	r.execute(ansi.EraseEntireScreen)
 }
 
 // execute writes ANSI escape sequence to output stream
@@ -112,9 +204,7 @@ func (r *StandardRenderer) execute(seq string) {
 
 // Kill forcefully stops the renderer and clears the current line
 func (r *StandardRenderer) Kill() {
-	r.once.Do(func() {
-		r.done <- struct{}{}
-	})
+	r.stopListening()
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -123,7 +213,22 @@ func (r *StandardRenderer) Kill() {
 	r.execute("\r")
 }
 
-// listen runs the main render loop, waiting for timer ticks or shutdown signal
+// stopListening ends the render loop if it is running. Stopping a renderer
+// that was never started does nothing.
+func (r *StandardRenderer) stopListening() {
+	r.mtx.Lock()
+	running := r.running
+	r.running = false
+	r.mtx.Unlock()
+
+	if running {
+		r.done <- struct{}{}
+	}
+}
+
+// listen runs the main render loop, waiting for timer ticks or shutdown signal.
+// The ticker is stopped in on-demand mode, so no ticks arrive until SetFrameRate
+// switches back to a frame rate.
 func (r *StandardRenderer) listen() {
 	for {
 		select {
@@ -141,7 +246,27 @@ func (r *StandardRenderer) flush() {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	if r.buf.Len() == 0 || r.buf.String() == r.lastRender {
+	if r.nextCells != nil {
+		if r.altScreenActive {
+			r.flushCells()
+			return
+		}
+		r.nextCells = nil
+	}
+
+	if r.altScreenActive {
+		// Printed lines only go to the scrollback of the main screen
+		r.queuedMessageLines = nil
+	}
+
+	if r.buf.Len() == 0 {
+		return
+	}
+	if r.buf.String() == r.lastRender && len(r.queuedMessageLines) == 0 {
+		if r.pending {
+			r.stats.Skipped++
+			r.pending = false
+		}
 		return
 	}
 
@@ -149,7 +274,8 @@ func (r *StandardRenderer) flush() {
 
 	if r.altScreenActive {
 		buf.WriteString(ansi.CursorHomePosition)
-	} else if r.linesRendered < 1 {
+	} else if r.linesRendered > 1 {
+		// Move from the last line of the previous frame back to its first line
 		buf.WriteString(ansi.CursorUp(r.linesRendered - 1))
 	}
 
@@ -162,8 +288,13 @@ func (r *StandardRenderer) flush() {
 	flushQueuedMessages := len(r.queuedMessageLines) > 0 && !r.altScreenActive
 
 	if flushQueuedMessages {
+		// Printed lines take the place of the previous frame, which is then
+		// redrawn below them
+		if r.lastRender == "" {
+			buf.WriteByte('\r')
+		}
 		for _, line := range r.queuedMessageLines {
-			if ansi.StringWidth(line) > r.width {
+			if ansi.StringWidth(line) < r.width {
 				line = line + ansi.EraseLineRight
 			}
 			_, _ = buf.WriteString(line)
@@ -174,7 +305,7 @@ func (r *StandardRenderer) flush() {
 	}
 
 	for i := 0; i < len(newLines); i++ {
-		canSkip := flushQueuedMessages &&
+		canSkip := !flushQueuedMessages &&
 			len(r.lastRenderedLines) > i && r.lastRenderedLines[i] == newLines[i]
 
 		if _, ignore := r.ignoreLines[i]; ignore || canSkip {
@@ -194,7 +325,8 @@ func (r *StandardRenderer) flush() {
 			line = ansi.Truncate(line, r.width, "")
 		}
 
-		if ansi.StringWidth(line) > r.width {
+		if ansi.StringWidth(line) < r.width {
+			// Clear what is left of the previous frame's line
 			line = line + ansi.EraseLineRight
 		}
 
@@ -206,7 +338,11 @@ func (r *StandardRenderer) flush() {
 	}
 
 	if r.lastLinesRendered() > len(newLines) {
+		// Erase from the line below the frame: the cursor is still at the
+		// start of the last line when it was unchanged and skipped
+		buf.WriteString("\r\n")
 		buf.WriteString(ansi.EraseScreenBelow)
+		buf.WriteString(ansi.CursorUp(1))
 	}
 	if r.altScreenActive {
 		r.altLinesRendered = len(newLines)
@@ -220,11 +356,31 @@ func (r *StandardRenderer) flush() {
 		buf.WriteByte('\r')
 	}
 
-	_, _ = r.out.Write(buf.Bytes())
+	r.writeFrame(buf)
+	r.stats.Rendered++
+	r.pending = false
 	r.lastRender = r.buf.String()
 
 	r.lastRenderedLines = newLines
-	r.buf.Reset()
+}
+
+// writeFrame writes a frame to the output in a single call, wrapped in
+// synchronized output mode when enabled so the terminal draws it at once
+func (r *StandardRenderer) writeFrame(buf *bytes.Buffer) {
+	if buf.Len() == 0 {
+		return
+	}
+	r.stats.Bytes += int64(buf.Len())
+	if r.syncOutput {
+		r.stats.Bytes += int64(len(ansi.SetSynchronizedOutputMode) + len(ansi.ResetSynchronizedOutputMode))
+		frame := make([]byte, 0, buf.Len()+len(ansi.SetSynchronizedOutputMode)+len(ansi.ResetSynchronizedOutputMode))
+		frame = append(frame, ansi.SetSynchronizedOutputMode...)
+		frame = append(frame, buf.Bytes()...)
+		frame = append(frame, ansi.ResetSynchronizedOutputMode...)
+		_, _ = r.out.Write(frame)
+		return
+	}
+	_, _ = r.out.Write(buf.Bytes())
 }
 
 // lastLinesRendered returns appropriate line count based on screen mode
@@ -243,13 +399,25 @@ func (r *StandardRenderer) ClearScreen() {
 	r.execute(ansi.EraseEntireScreen)
 	r.execute(ansi.CursorHomePosition)
 
-	r.Repaint()
+	r.repaint()
 }
 
 // Repaint resets render state to force full redraw on next flush
 func (r *StandardRenderer) Repaint() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.repaint()
+}
+
+// repaint is Repaint for callers already holding r.mtx
+func (r *StandardRenderer) repaint() {
 	r.lastRender = ""
 	r.lastRenderedLines = nil
+	if r.lastCells != nil && r.nextCells == nil {
+		r.nextCells = r.lastCells
+	}
+	r.lastCells = nil
 }
 
 // AltScreen returns whether alternate screen buffer is currently active
@@ -283,7 +451,7 @@ func (r *StandardRenderer) EnterAltScreen() {
 
 	r.altLinesRendered = 0
 
-	r.Repaint()
+	r.repaint()
 }
 
 // ExitAltScreen restores normal screen buffer and cursor position
@@ -304,7 +472,7 @@ func (r *StandardRenderer) ExitAltScreen() {
 		r.execute(ansi.ShowCursor)
 	}
 
-	r.Repaint()
+	r.repaint()
 }
 
 // ShowCursor makes the terminal cursor visible
@@ -333,14 +501,24 @@ func (r *StandardRenderer) SetWindowTitle(title string) {
 // Write stores content in render buffer, replacing any previous content
 func (r *StandardRenderer) Write(s string) {
 	r.mtx.Lock()
-	defer r.mtx.Unlock()
+	if r.pending {
+		// The previous frame was never flushed
+		r.stats.Skipped++
+	}
 	r.buf.Reset()
 
 	if s == "" {
 		s = " "
 	}
 
-	_, _ = r.buf.WriteString(s)
+	_, _ = r.buf.WriteString(r.colorProfile.ConvertString(s))
+	r.pending = true
+	flush := r.onDemand && r.running
+	r.mtx.Unlock()
+
+	if flush {
+		r.flush()
+	}
 }
 
 // SetCursor positions cursor at specific coordinates in alternate screen mode
@@ -359,3 +537,151 @@ func (r *StandardRenderer) GetSize() (int, int) {
 	defer r.mtx.Unlock()
 	return r.width, r.height
 }
+
+// Resize updates the dimensions used for truncation and clipping and forces a full repaint
+func (r *StandardRenderer) Resize(width, height int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.width = width
+	r.height = height
+
+	if r.altScreenActive {
+		r.execute(ansi.EraseEntireScreen)
+	}
+
+	r.repaint()
+}
+
+// SetColorProfile sets the color profile output colors are converted to and
+// repaints the screen
+func (r *StandardRenderer) SetColorProfile(p ColorProfile) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.colorProfile = p
+	r.repaint()
+}
+
+// ColorProfile returns the color profile output colors are converted to
+func (r *StandardRenderer) ColorProfile() ColorProfile {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.colorProfile
+}
+
+// SetSynchronizedOutput enables or disables wrapping each frame in
+// synchronized output mode (2026) to avoid tearing
+func (r *StandardRenderer) SetSynchronizedOutput(enabled bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.syncOutput = enabled
+}
+
+// SynchronizedOutput returns whether frames are wrapped in synchronized output mode
+func (r *StandardRenderer) SynchronizedOutput() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.syncOutput
+}
+
+// PrintLine queues s to be printed above the view on the next flush. The line
+// becomes part of the terminal's scrollback. It is dropped in the alternate
+// screen.
+func (r *StandardRenderer) PrintLine(s string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.altScreenActive {
+		return
+	}
+	r.queuedMessageLines = append(r.queuedMessageLines, strings.Split(s, "\n")...)
+}
+
+// SetFrameRate sets how many frames per second are flushed, up to 120. With
+// fps 0 or less the renderer runs on demand: every written frame is flushed
+// immediately instead of on the next tick.
+func (r *StandardRenderer) SetFrameRate(fps int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if fps <= 0 {
+		r.onDemand = true
+		r.ticker.Stop()
+		return
+	}
+
+	r.onDemand = false
+	r.frameRate = time.Second / time.Duration(min(fps, maxFPS))
+	if r.running {
+		r.ticker.Reset(r.frameRate)
+	}
+}
+
+// Stats returns the frame pacing statistics collected since the renderer was created
+func (r *StandardRenderer) Stats() RenderStats {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	return r.stats
+}
+
+// EnableMouseCellMotion turns on reporting of clicks, wheel and motion while a button is held
+func (r *StandardRenderer) EnableMouseCellMotion() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.SetButtonEventMouseMode)
+}
+
+// DisableMouseCellMotion turns off cell motion mouse reporting
+func (r *StandardRenderer) DisableMouseCellMotion() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.ResetButtonEventMouseMode)
+}
+
+// EnableMouseAllMotion turns on reporting of every mouse event, including hover motion
+func (r *StandardRenderer) EnableMouseAllMotion() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.SetAnyEventMouseMode)
+}
+
+// DisableMouseAllMotion turns off all motion mouse reporting
+func (r *StandardRenderer) DisableMouseAllMotion() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.ResetAnyEventMouseMode)
+}
+
+// EnableMouseSGRMode switches mouse reports to the SGR (1006) encoding,
+// which supports coordinates beyond column 223
+func (r *StandardRenderer) EnableMouseSGRMode() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.SetSgrExtMouseMode)
+}
+
+// DisableMouseSGRMode switches mouse reports back to the X10 encoding
+func (r *StandardRenderer) DisableMouseSGRMode() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.ResetSgrExtMouseMode)
+}
+
+// EnableBracketedPaste asks the terminal to wrap pasted text in paste markers
+func (r *StandardRenderer) EnableBracketedPaste() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.SetBracketedPasteMode)
+}
+
+// DisableBracketedPaste turns bracketed paste mode off
+func (r *StandardRenderer) DisableBracketedPaste() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.execute(ansi.ResetBracketedPasteMode)
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe to write from the render loop while a test reads it
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.buf.Reset()
+}
+
+func TestSetFrameRateLeavesOnDemandMode(t *testing.T) {
+	out := &syncBuffer{}
+	r := NewRenderer(out)
+	r.SetFrameRate(0)
+	r.Start()
+	defer r.Stop()
+
+	r.SetFrameRate(60)
+	r.Write("ticking")
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(out.String(), "ticking") {
+		if time.Now().After(deadline) {
+			t.Fatal("frame not flushed after switching to a frame rate")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestOnDemandWriteWhileStopped(t *testing.T) {
+	out := &syncBuffer{}
+	r := NewRenderer(out)
+	r.SetFrameRate(0)
+	r.Start()
+	r.Stop()
+	out.Reset()
+
+	r.Write("later")
+	if got := out.String(); got != "" {
+		t.Fatalf("stopped renderer wrote %q", got)
+	}
+
+	r.Start()
+	defer r.Stop()
+	if !strings.Contains(out.String(), "later") {
+		t.Errorf("pending frame not flushed on Start, output %q", out.String())
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package engine
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// listenForResize delivers a SizeMsg every time the terminal sends SIGWINCH
+// until the program exits
+func (p *Program) listenForResize() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGWINCH)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-sig:
+			p.handleResize()
+		}
+	}
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package engine
+
+// listenForResize is a no-op on Windows, which has no SIGWINCH
+func (p *Program) listenForResize() {}
@@ -0,0 +1,214 @@
+package engine
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Scroll areas let a component such as a log viewer paint a band of the
+// alternate screen directly, using the terminal's scroll region (DECSTBM)
+// instead of redrawing the whole band every frame. Areas are given as view
+// lines from top (inclusive) to bottom (exclusive), counted from 0.
+
+// syncScrollAreaMsg is returned by SyncScrollArea
+type syncScrollAreaMsg struct {
+	lines  []string
+	top    int
+	bottom int
+}
+
+// clearScrollAreaMsg is returned by ClearScrollArea
+type clearScrollAreaMsg struct{}
+
+// scrollUpMsg is returned by ScrollUp
+type scrollUpMsg struct {
+	lines  []string
+	top    int
+	bottom int
+}
+
+// scrollDownMsg is returned by ScrollDown
+type scrollDownMsg struct {
+	lines  []string
+	top    int
+	bottom int
+}
+
+// SyncScrollArea marks view lines top to bottom as a scroll area that the
+// renderer no longer draws, and paints lines into it. Call it again after a
+// resize or a ClearScreen, which erase the area.
+func SyncScrollArea(lines []string, top, bottom int) Cmd {
+	return func() Msg {
+		return syncScrollAreaMsg{lines: lines, top: top, bottom: bottom}
+	}
+}
+
+// ClearScrollArea hands the scroll area back to the renderer and repaints
+func ClearScrollArea() Cmd {
+	return func() Msg {
+		return clearScrollAreaMsg{}
+	}
+}
+
+// ScrollUp scrolls the area between top and bottom up by len(newLines) and
+// draws newLines at its bottom, e.g. to append to a log
+func ScrollUp(newLines []string, top, bottom int) Cmd {
+	return func() Msg {
+		return scrollUpMsg{lines: newLines, top: top, bottom: bottom}
+	}
+}
+
+// ScrollDown scrolls the area between top and bottom down by len(newLines)
+// and draws newLines at its top
+func ScrollDown(newLines []string, top, bottom int) Cmd {
+	return func() Msg {
+		return scrollDownMsg{lines: newLines, top: top, bottom: bottom}
+	}
+}
+
+// handleScrollAreaMsg applies the scroll area messages to the renderer and
+// reports whether msg was one of them
+func (p *Program) handleScrollAreaMsg(msg Msg) bool {
+	switch msg := msg.(type) {
+	case syncScrollAreaMsg:
+		p.renderer.ClearIgnoredLines()
+		p.renderer.SetIgnoredLines(msg.top, msg.bottom)
+		p.renderer.PaintLines(msg.lines, msg.top, msg.bottom)
+	case clearScrollAreaMsg:
+		p.renderer.ClearIgnoredLines()
+	case scrollUpMsg:
+		p.renderer.InsertBottom(msg.lines, msg.top, msg.bottom)
+	case scrollDownMsg:
+		p.renderer.InsertTop(msg.lines, msg.top, msg.bottom)
+	default:
+		return false
+	}
+	return true
+}
+
+// SetIgnoredLines stops the renderer from drawing view lines from to to
+// (exclusive), leaving them to another writer. The lines are cleared.
+func (r *StandardRenderer) SetIgnoredLines(from, to int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.ignoreLines == nil {
+		r.ignoreLines = make(map[int]struct{})
+	}
+
+	buf := &bytes.Buffer{}
+	for i := max(from, 0); i < to; i++ {
+		r.ignoreLines[i] = struct{}{}
+		if r.altScreenActive && (r.height <= 0 || i < r.height) {
+			buf.WriteString(ansi.CursorPosition(1, i+1))
+			buf.WriteString(ansi.EraseEntireLine)
+		}
+	}
+	r.execute(buf.String())
+}
+
+// ClearIgnoredLines lets the renderer draw every line again and repaints
+func (r *StandardRenderer) ClearIgnoredLines() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.ignoreLines = nil
+	r.repaint()
+}
+
+// PaintLines draws lines over view lines top to bottom in the alternate
+// screen, clearing the rows left over when there are fewer lines
+func (r *StandardRenderer) PaintLines(lines []string, top, bottom int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	top, bottom, ok := r.scrollArea(top, bottom)
+	if !ok {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	for row := top; row < bottom; row++ {
+		buf.WriteString(ansi.CursorPosition(1, row+1))
+		buf.WriteString(ansi.EraseEntireLine)
+		if i := row - top; i < len(lines) {
+			buf.WriteString(r.scrollAreaLine(lines[i]))
+		}
+	}
+	r.execute(buf.String())
+}
+
+// InsertTop scrolls view lines top to bottom down and draws lines at the top
+// of the area. It only applies to the alternate screen.
+func (r *StandardRenderer) InsertTop(lines []string, top, bottom int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	top, bottom, ok := r.scrollArea(top, bottom)
+	if !ok || len(lines) == 0 {
+		return
+	}
+	lines = lines[:min(len(lines), bottom-top)]
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(ansi.SetTopBottomMargins(top+1, bottom))
+	buf.WriteString(ansi.ScrollDown(len(lines)))
+	for i, line := range lines {
+		buf.WriteString(ansi.CursorPosition(1, top+i+1))
+		buf.WriteString(r.scrollAreaLine(line))
+	}
+	buf.WriteString(ansi.SetTopBottomMargins(0, 0))
+	r.execute(buf.String())
+}
+
+// InsertBottom scrolls view lines top to bottom up and draws lines at the
+// bottom of the area. It only applies to the alternate screen.
+func (r *StandardRenderer) InsertBottom(lines []string, top, bottom int) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	top, bottom, ok := r.scrollArea(top, bottom)
+	if !ok || len(lines) == 0 {
+		return
+	}
+	lines = lines[len(lines)-min(len(lines), bottom-top):]
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(ansi.SetTopBottomMargins(top+1, bottom))
+	buf.WriteString(ansi.ScrollUp(len(lines)))
+	for i, line := range lines {
+		buf.WriteString(ansi.CursorPosition(1, bottom-len(lines)+i+1))
+		buf.WriteString(r.scrollAreaLine(line))
+	}
+	buf.WriteString(ansi.SetTopBottomMargins(0, 0))
+	r.execute(buf.String())
+}
+
+// scrollArea clips an area to the screen. It reports false outside the
+// alternate screen, where view lines have no fixed screen rows.
+func (r *StandardRenderer) scrollArea(top, bottom int) (int, int, bool) {
+	if !r.altScreenActive {
+		return 0, 0, false
+	}
+	top = max(top, 0)
+	if r.height > 0 {
+		bottom = min(bottom, r.height)
+	}
+	return top, bottom, top < bottom
+}
+
+// scrollAreaLine prepares a line painted into a scroll area: colors are
+// converted for the profile, the line is cut to the screen width and any
+// style is reset at its end
+func (r *StandardRenderer) scrollAreaLine(line string) string {
+	line = r.colorProfile.ConvertString(line)
+	if r.width > 0 {
+		line = ansi.Truncate(line, r.width, "")
+	}
+	if strings.Contains(line, "\x1b[") {
+		line += ansi.ResetStyle
+	}
+	return line
+}
@@ -0,0 +1,69 @@
+package engine_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// logViewer hands view lines 1 to 4 to a scroll area driven by its keys; "n"
+// changes the view
+type logViewer struct {
+	n int
+}
+
+func (m logViewer) Init() engine.Msg { return nil }
+
+func (m logViewer) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	key, ok := msg.(engine.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "s":
+		return m, engine.SyncScrollArea([]string{"a", "b", "c"}, 1, 4)
+	case "u":
+		return m, engine.ScrollUp([]string{"d"}, 1, 4)
+	case "w":
+		return m, engine.ScrollDown([]string{"z"}, 1, 4)
+	case "c":
+		return m, engine.ClearScrollArea()
+	case "n":
+		m.n++
+	case "q":
+		return m, engine.Quit
+	}
+	return m, nil
+}
+
+func (m logViewer) View() string {
+	return fmt.Sprintf("header %d\nbody %d\nbody %d\nbody %d\nfooter", m.n, m.n, m.n, m.n)
+}
+
+func TestScrollArea(t *testing.T) {
+	tm := enginetest.NewTestModel(t, logViewer{}, enginetest.WithInitialSize(20, 5),
+		enginetest.WithProgramOptions(engine.WithAltScreen()))
+
+	steps := []struct {
+		key  string
+		want []string
+	}{
+		{"", []string{"header 0", "body 0", "body 0", "body 0", "footer"}},
+		{"s", []string{"header 0", "a", "b", "c", "footer"}},
+		{"u", []string{"header 0", "b", "c", "d", "footer"}},
+		{"w", []string{"header 0", "z", "b", "c", "footer"}},
+		// The renderer keeps drawing the rest of the view around the area
+		{"n", []string{"header 1", "z", "b", "c", "footer"}},
+		{"c", []string{"header 1", "body 1", "body 1", "body 1", "footer"}},
+	}
+	for _, step := range steps {
+		tm.Type(step.key)
+		want := strings.Join(step.want, "\n")
+		tm.WaitFor(func(s *enginetest.Screen) bool { return s.String() == want })
+	}
+
+	tm.Quit()
+}
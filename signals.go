@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// listenForSignals turns SIGINT into an InterruptMsg and SIGTERM/SIGHUP into a
+// QuitMsg so Run can restore the terminal before the process exits. In raw mode
+// Ctrl+C is read as input instead, so SIGINT only arrives from outside.
+// SIGINT is dropped while the terminal is released: the child process of
+// ExecProcess, or the shell after Suspend, owns it and a Ctrl+C typed there is
+// meant for them. SIGTERM and SIGHUP still quit; the QuitMsg is handled once
+// the main loop runs again, after the terminal has been restored.
+func (p *Program) listenForSignals() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case s := <-sig:
+			if s == syscall.SIGINT {
+				if !p.ttyReleased.Load() {
+					p.Send(InterruptMsg{})
+				}
+			} else {
+				p.Send(QuitMsg{})
+			}
+		}
+	}
+}
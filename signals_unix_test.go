@@ -0,0 +1,66 @@
+//go:build !windows
+
+package engine_test
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+)
+
+// execModel runs cmd from Init and records the error it exits with
+type execModel struct {
+	cmd *exec.Cmd
+}
+
+type execDoneMsg struct{ err error }
+
+func (m execModel) Init() engine.Msg { return nil }
+
+func (m execModel) Update(msg engine.Msg) (engine.Model, engine.Cmd) {
+	if msg == nil {
+		return m, engine.ExecProcess(m.cmd, func(err error) engine.Msg { return execDoneMsg{err} })
+	}
+	return m, nil
+}
+
+func (m execModel) View() string { return "" }
+
+func TestSignalsWhileTerminalReleased(t *testing.T) {
+	// Keep the test process alive if a signal arrives before the program listens
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	in, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	defer inW.Close()
+
+	// The child owns the terminal when it sends SIGINT, which must be ignored,
+	// and SIGTERM, which must stop the program once the child exits
+	script := "sleep 0.1; kill -INT $PPID; kill -TERM $PPID; sleep 0.1"
+	p := engine.NewProgram(execModel{cmd: exec.Command("sh", "-c", script)},
+		engine.WithInput(in), engine.WithOutput(io.Discard), engine.WithWindowSize(20, 5))
+
+	errs := make(chan error, 1)
+	go func() { errs <- p.Run() }()
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("Run returned %v, want nil after SIGTERM", err)
+		}
+	case <-time.After(3 * time.Second):
+		p.Kill()
+		t.Fatal("SIGTERM received while the terminal was released did not stop the program")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package engine
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// suspendProcess stops the process group with SIGTSTP and blocks until SIGCONT
+func suspendProcess() {
+	cont := make(chan os.Signal, 1)
+	signal.Notify(cont, syscall.SIGCONT)
+	defer signal.Stop(cont)
+
+	_ = syscall.Kill(0, syscall.SIGTSTP)
+
+	<-cont
+}
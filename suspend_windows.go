@@ -0,0 +1,6 @@
+//go:build windows
+
+package engine
+
+// suspendProcess is a no-op on Windows, which has no job control signals
+func suspendProcess() {}
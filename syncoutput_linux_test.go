@@ -0,0 +1,137 @@
+package engine_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+	"golang.org/x/sys/unix"
+
+	engine "github.com/skyvence/TerminalEngineGo"
+	"github.com/skyvence/TerminalEngineGo/enginetest"
+)
+
+// openPTY returns the master and slave ends of a new pseudo terminal of the
+// given size
+func openPTY(t *testing.T, width, height int) (*os.File, *os.File) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminals: %v", err)
+	}
+	fd := int(master.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo terminals: %v", err)
+	}
+	ws := &unix.Winsize{Col: uint16(width), Row: uint16(height)}
+	if err := unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = slave.Close()
+		_ = master.Close()
+	})
+	return master, slave
+}
+
+// recorder keeps every byte written to it
+type recorder struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.buf.Write(p)
+}
+
+func (r *recorder) Contains(s string) bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return bytes.Contains(r.buf.Bytes(), []byte(s))
+}
+
+// waitUntil fails the test if cond does not hold within a second
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSynchronizedOutputQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply string
+		want  bool
+	}{
+		{"recognized", "\x1b[?2026;2$y", true},
+		{"not recognized", "\x1b[?2026;0$y", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master, slave := openPTY(t, 20, 4)
+			screen := enginetest.NewScreen(20, 4)
+			out := &recorder{}
+			go func() { _, _ = io.Copy(io.MultiWriter(screen, out), master) }()
+
+			p := engine.NewProgram(frames{}, engine.WithInput(slave), engine.WithOutput(slave),
+				engine.WithoutSignalHandler())
+			errc := make(chan error, 1)
+			go func() {
+				errc <- p.Run()
+			}()
+
+			waitUntil(t, "the DECRQM query", func() bool { return out.Contains(ansi.RequestSynchronizedOutputMode) })
+			if _, err := master.WriteString(tt.reply); err != nil {
+				t.Fatal(err)
+			}
+
+			// The reply is handled by the event loop some time after it is
+			// written, so redraw a few frames before checking how they were sent
+			for i := 1; i <= 10 && !out.Contains(ansi.SetSynchronizedOutputMode); i++ {
+				before := screen.String()
+				p.Send(i)
+				waitUntil(t, "a new frame", func() bool { return screen.String() != before })
+			}
+
+			if got := out.Contains(ansi.SetSynchronizedOutputMode); got != tt.want {
+				t.Errorf("synchronized frames = %v, want %v", got, tt.want)
+			}
+
+			if _, err := master.WriteString("q"); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case err := <-errc:
+				if err != nil {
+					t.Errorf("Run: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Run did not return")
+			}
+		})
+	}
+}
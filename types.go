@@ -12,8 +12,10 @@ type Model interface {
 	View() string
 }
 
-type KeyMsg struct {
-	Rune rune
+// PasteMsg carries text pasted while bracketed paste mode is enabled.
+// Line endings are normalised to "\n".
+type PasteMsg struct {
+	Text string
 }
 
 type QuitMsg struct{}
@@ -22,6 +24,15 @@ func Quit() Msg {
 	return QuitMsg{}
 }
 
+// InterruptMsg stops the program like QuitMsg but makes Run return ErrInterrupted.
+// It is sent when the process receives SIGINT.
+type InterruptMsg struct{}
+
+// Interrupt is a command that sends an InterruptMsg
+func Interrupt() Msg {
+	return InterruptMsg{}
+}
+
 // TickMsg is a message that is sent on a timer.
 type TickMsg struct {
 	// The time the tick occurred.
@@ -43,6 +54,19 @@ func TickNow() Cmd {
 	}
 }
 
+// SuspendMsg asks the program to suspend like a shell job: the terminal is
+// restored and the process is stopped until it receives SIGCONT
+type SuspendMsg struct{}
+
+// Suspend is a command that sends a SuspendMsg, typically bound to ctrl+z
+func Suspend() Msg {
+	return SuspendMsg{}
+}
+
+// ResumeMsg is sent once a suspended program has been continued and has
+// taken the terminal back
+type ResumeMsg struct{}
+
 type SizeMsg struct {
 	Width  int
 	Height int
@@ -53,3 +77,10 @@ type PixelModel interface {
 	Model
 	PixelView() *PixelBuffer
 }
+
+// CanvasModel extends Model with a high-resolution Canvas view, rendered by
+// the pixel renderer with half or quadrant blocks
+type CanvasModel interface {
+	Model
+	CanvasView() *Canvas
+}